@@ -0,0 +1,262 @@
+//go:build !windows
+// +build !windows
+
+// Command ptyd hosts pty sessions which survive their clients, like dtach or
+// tmux, see the session package.
+//
+// Usage:
+//
+//	ptyd [-socket path] serve
+//	ptyd [-socket path] attach [-r] NAME [COMMAND [ARG...]]
+//	ptyd [-socket path] list
+//	ptyd [-socket path] signal NAME SIGNAL
+//
+// serve runs the daemon, until interrupted. attach attaches the terminal to
+// the session NAME, started with COMMAND, $SHELL by default, if it does not
+// exist. With -r, the input is discarded. Ctrl-\ detaches, and the session
+// keeps running. list lists the sessions. signal sends SIGNAL, e.g. INT, to
+// the foreground process group of the session NAME.
+//
+// The default socket is created in a directory only accessible by the user,
+// ptyd in XDG_RUNTIME_DIR if set, ptyd-UID in the temporary directory
+// otherwise.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/creack/pty"
+	"github.com/creack/pty/session"
+)
+
+// detachKey detaches the client, Ctrl-\ as dtach.
+const detachKey = 0x1c
+
+// signals are the signals known by name.
+var signals = map[string]syscall.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"KILL":  syscall.SIGKILL,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"TERM":  syscall.SIGTERM,
+	"CONT":  syscall.SIGCONT,
+	"STOP":  syscall.SIGSTOP,
+	"TSTP":  syscall.SIGTSTP,
+	"WINCH": syscall.SIGWINCH,
+}
+
+func main() {
+	socket := flag.String("socket", "", "path of the Unix domain socket (default in a directory private to the user)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n"+
+			"  %[1]s [-socket path] serve\n"+
+			"  %[1]s [-socket path] attach [-r] NAME [COMMAND [ARG...]]\n"+
+			"  %[1]s [-socket path] list\n"+
+			"  %[1]s [-socket path] signal NAME SIGNAL\n"+
+			"Flags:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	if *socket == "" {
+		if *socket, err = defaultSocket(); err != nil {
+			fmt.Fprintf(os.Stderr, "ptyd: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	switch cmd, args := args[0], args[1:]; {
+	case cmd == "serve" && len(args) == 0:
+		err = serve(*socket)
+	case cmd == "attach":
+		fs := flag.NewFlagSet("attach", flag.ExitOnError)
+		readOnly := fs.Bool("r", false, "attach read-only")
+		_ = fs.Parse(args) // Exits on error.
+		if fs.NArg() == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		var code int
+		if code, err = attach(*socket, fs.Arg(0), fs.Args()[1:], *readOnly); err == nil && code >= 0 {
+			os.Exit(code)
+		}
+	case cmd == "list" && len(args) == 0:
+		var names []string
+		if names, err = session.List(*socket); err == nil && len(names) > 0 {
+			fmt.Println(strings.Join(names, "\n"))
+		}
+	case cmd == "signal" && len(args) == 2:
+		err = sendSignal(*socket, args[0], args[1])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ptyd: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// defaultSocket returns the default path of the socket, in a directory
+// private to the user, created if needed. As the directory may have been
+// created by another user, e.g. in /tmp, it must be owned by the user and
+// not accessible by the others.
+func defaultSocket() (string, error) {
+	dir := filepath.Join(os.TempDir(), "ptyd-"+strconv.Itoa(os.Getuid()))
+	if xdg := os.Getenv("XDG_RUNTIME_DIR"); xdg != "" {
+		dir = filepath.Join(xdg, "ptyd")
+	}
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", err
+	}
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); !ok || !fi.IsDir() || int(st.Uid) != os.Getuid() || fi.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("unsafe socket directory %s: not a directory owned by the user with mode 0700", dir)
+	}
+	return filepath.Join(dir, "default"), nil
+}
+
+// serve hosts the sessions on the socket path until interrupted, and then
+// hangs them up.
+func serve(path string) error {
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close() // Best effort.
+		return fmt.Errorf("already serving on %s", path)
+	}
+	_ = os.Remove(path) // Stale socket, if any.
+	// Only the user may connect, from the creation of the socket.
+	mask := syscall.Umask(0o177)
+	l, err := net.Listen("unix", path)
+	syscall.Umask(mask)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Close() }() // Best effort.
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-sigs
+		_ = l.Close() // Best effort.
+	}()
+
+	s := session.NewServer()
+	defer func() { _ = s.Close() }() // Best effort.
+	if err := s.Serve(l); !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// attach attaches the terminal to the session name, started with command if
+// it does not exist. It returns the exit code of the program once the
+// session ended, -1 if detached.
+func attach(path, name string, command []string, readOnly bool) (int, error) {
+	if len(command) == 0 {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		command = []string{shell}
+	}
+	req := &session.AttachRequest{Name: name, Command: command, ReadOnly: readOnly}
+	if ws, err := pty.GetsizeFull(os.Stdin); err == nil {
+		req.Size = ws
+	}
+	c, err := session.Dial(path, req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = c.Close() }() // Best effort.
+
+	if state, err := pty.MakeRaw(os.Stdin); err == nil {
+		defer func() { _ = pty.Restore(os.Stdin, state) }() // Best effort.
+	}
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	go func() {
+		for range winch {
+			if ws, err := pty.GetsizeFull(os.Stdin); err == nil {
+				_ = c.Resize(ws) // Best effort.
+			}
+		}
+	}()
+	go copyInput(c, os.Stdin)
+
+	if _, err := io.Copy(os.Stdout, c); err != nil {
+		return 0, err
+	}
+	if code, ok := c.ExitCode(); ok {
+		return code, nil
+	}
+	fmt.Fprint(os.Stderr, "\r\n[detached]\r\n")
+	return -1, nil
+}
+
+// copyInput writes the input read from r to c, until the end of the input
+// or the detach key, which detaches c.
+func copyInput(c *session.Client, r io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if i := bytes.IndexByte(buf[:n], detachKey); i >= 0 {
+			_, _ = c.Write(buf[:i]) // Best effort.
+			_ = c.Detach()          // Best effort.
+			return
+		}
+		if n > 0 {
+			if _, err := c.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sendSignal sends the signal sig, a name or a number, to the foreground
+// process group of the session name.
+func sendSignal(path, name, sig string) error {
+	s, ok := signals[strings.TrimPrefix(strings.ToUpper(sig), "SIG")]
+	if !ok {
+		n, err := strconv.Atoi(sig)
+		if err != nil {
+			return fmt.Errorf("unknown signal %q", sig)
+		}
+		s = syscall.Signal(n)
+	}
+
+	c, err := session.Dial(path, &session.AttachRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	if err := c.Signal(s); err != nil {
+		_ = c.Close() // Best effort.
+		return err
+	}
+	return c.Detach()
+}
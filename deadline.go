@@ -0,0 +1,18 @@
+//go:build go1.12
+// +build go1.12
+
+package pty
+
+import "time"
+
+// SetDeadline sets the read and write deadlines of the pty side.
+// See (*os.File).SetDeadline().
+func (p *Pty) SetDeadline(t time.Time) error {
+	return p.master.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the pty side.
+// See (*os.File).SetReadDeadline().
+func (p *Pty) SetReadDeadline(t time.Time) error {
+	return p.master.SetReadDeadline(t)
+}
@@ -14,3 +14,35 @@ var ErrUnsupported = errors.New("unsupported")
 func Open() (pty, tty *os.File, err error) {
 	return open()
 }
+
+// OpenOptions configures OpenWithOptions.
+type OpenOptions struct {
+	// NonBlocking opens the pty in non-blocking mode and registers it with
+	// the runtime poller, so deadlines are supported and Close interrupts
+	// outstanding Read calls.
+	//
+	// Only supported on Linux.
+	NonBlocking bool
+
+	// PtmxPath is the path of the ptmx device to open instead of /dev/ptmx,
+	// e.g. the ptmx of a devpts instance. When DevptsFD is set, a relative
+	// path is resolved from it and defaults to "ptmx".
+	//
+	// The tty is then resolved from the pty with TIOCGPTPEER, so it belongs
+	// to the devpts instance of the ptmx. Its name is given as /dev/pts/N,
+	// which is only meaningful where that devpts instance is mounted.
+	//
+	// Only supported on Linux 4.13+.
+	PtmxPath string
+
+	// DevptsFD is a file descriptor of the directory PtmxPath is relative to,
+	// typically a devpts mount, see openat(2). Nil means unset.
+	//
+	// Only supported on Linux 4.13+.
+	DevptsFD *int
+}
+
+// OpenWithOptions opens a pty and its corresponding tty as described by opts.
+func OpenWithOptions(opts OpenOptions) (pty, tty *os.File, err error) {
+	return openWithOptions(opts)
+}
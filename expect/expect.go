@@ -0,0 +1,216 @@
+// Package expect automates interactive programs run in a pty: wait for some
+// output, then send some input, in the spirit of expect(1).
+package expect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// Defaults of the Expecter options.
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultBufferSize = 64 * 1024
+)
+
+// ErrTimeout is returned by Expect when no pattern matched in time.
+var ErrTimeout = errors.New("expect: timeout")
+
+// ErrEOF is returned by Expect when the output ended without matching
+// any pattern. Use the EOF pattern to expect the end of the output.
+var ErrEOF = errors.New("expect: unexpected end of output")
+
+// Option configures an Expecter.
+type Option func(*Expecter)
+
+// WithTimeout sets the time Expect waits for a match, unless the context
+// expires first. Zero or less disables the timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(e *Expecter) { e.timeout = d }
+}
+
+// WithBufferSize sets the maximum size of the match buffer.
+// When full, the oldest output is discarded.
+func WithBufferSize(n int) Option {
+	return func(e *Expecter) { e.bufSize = n }
+}
+
+// WithSize sets the initial size of the pty.
+func WithSize(ws *pty.Winsize) Option {
+	return func(e *Expecter) { e.size = ws }
+}
+
+// Expecter drives a command running in a pty.
+type Expecter struct {
+	pty     *pty.Pty
+	timeout time.Duration
+	bufSize int
+	size    *pty.Winsize
+
+	mu      sync.Mutex
+	buf     []byte        // Output not consumed by a match yet.
+	err     error         // Read error, the output ended when set.
+	changed chan struct{} // Closed and replaced when buf or err change.
+	done    chan struct{} // Closed when the read loop returns.
+}
+
+// Match describes the result of a successful Expect.
+type Match struct {
+	// Index is the index of the matching pattern in the Expect arguments.
+	Index int
+
+	// Groups holds the matching text followed by the text of the captured
+	// groups, as regexp.FindStringSubmatch. Unmatched groups are empty.
+	Groups []string
+
+	// Before is the output preceding the match.
+	Before string
+}
+
+// Spawn starts cmd in a new pty and returns the Expecter driving it.
+func Spawn(cmd *exec.Cmd, opts ...Option) (*Expecter, error) {
+	e := &Expecter{
+		timeout: DefaultTimeout,
+		bufSize: DefaultBufferSize,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.bufSize <= 0 {
+		return nil, fmt.Errorf("expect: invalid buffer size %d", e.bufSize)
+	}
+
+	p, err := pty.StartPty(cmd, e.size)
+	if err != nil {
+		return nil, err
+	}
+	e.pty = p
+	go e.readLoop()
+	return e, nil
+}
+
+// Pty returns the pty running the command.
+func (e *Expecter) Pty() *pty.Pty {
+	return e.pty
+}
+
+func (e *Expecter) readLoop() {
+	defer close(e.done)
+
+	b := make([]byte, 32*1024)
+	for {
+		n, err := e.pty.Read(b)
+
+		e.mu.Lock()
+		e.buf = append(e.buf, b[:n]...)
+		if over := len(e.buf) - e.bufSize; over > 0 {
+			e.buf = append(e.buf[:0], e.buf[over:]...)
+		}
+		if err != nil {
+			// On Linux, reading the pty fails with EIO once the tty is closed.
+			e.err = err
+		}
+		close(e.changed)
+		e.changed = make(chan struct{})
+		e.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Expect waits for the output of the command to match one of patterns and
+// consumes the output up to the end of the match. Patterns are tried in
+// order, the first matching one wins.
+//
+// When nothing matches, ErrTimeout is returned after the Expecter timeout,
+// ErrEOF once the output ended, or the context error if ctx is done first.
+func (e *Expecter) Expect(ctx context.Context, patterns ...Pattern) (*Match, error) {
+	var timeout <-chan time.Time
+	if e.timeout > 0 {
+		timer := time.NewTimer(e.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		e.mu.Lock()
+		eof := e.err != nil
+		for i, p := range patterns {
+			loc := p.match(e.buf, eof)
+			if loc == nil {
+				continue
+			}
+			m := &Match{Index: i, Groups: make([]string, len(loc)/2), Before: string(e.buf[:loc[0]])}
+			for j := range m.Groups {
+				if loc[2*j] >= 0 {
+					m.Groups[j] = string(e.buf[loc[2*j]:loc[2*j+1]])
+				}
+			}
+			e.buf = e.buf[loc[1]:]
+			e.mu.Unlock()
+			return m, nil
+		}
+		changed, buf := e.changed, string(e.buf)
+		e.mu.Unlock()
+
+		if eof {
+			return nil, fmt.Errorf("%w, output: %q", ErrEOF, buf)
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			return nil, fmt.Errorf("%w after %s, output: %q", ErrTimeout, e.timeout, buf)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Buffer returns the output not consumed by a match yet.
+func (e *Expecter) Buffer() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return string(e.buf)
+}
+
+// Send writes s to the command.
+func (e *Expecter) Send(s string) error {
+	_, err := e.pty.Write([]byte(s))
+	return err
+}
+
+// SendLine writes s followed by a carriage return, as the Enter key does.
+func (e *Expecter) SendLine(s string) error {
+	return e.Send(s + "\r")
+}
+
+// Wait waits for the command to exit, see (*exec.Cmd).Wait.
+func (e *Expecter) Wait() error {
+	return e.pty.Wait()
+}
+
+// Close closes the pty and waits for the read loop to return.
+// It does not wait for the command.
+//
+// Closing only interrupts the read loop where the pty is non-blocking, see
+// pty.OpenOptions. Elsewhere, the read loop returns once the tty is closed
+// by the command.
+func (e *Expecter) Close() error {
+	// Deadlines are only supported by a non-blocking pty.
+	interruptible := e.pty.Master().SetReadDeadline(time.Time{}) == nil
+	err := e.pty.Close()
+	if interruptible {
+		<-e.done
+	}
+	return err
+}
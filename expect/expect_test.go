@@ -0,0 +1,138 @@
+//go:build !windows
+// +build !windows
+
+package expect
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// spawn starts the command and stages its termination as part of the cleanup.
+func spawn(t *testing.T, c *exec.Cmd, opts ...Option) *Expecter {
+	t.Helper()
+
+	e, err := Spawn(c, opts...)
+	if err != nil {
+		t.Fatalf("Unexpected error from Spawn: %s.", err)
+	}
+	t.Cleanup(func() {
+		_ = e.Close()           // Best effort.
+		_ = c.Process.Kill()    // Best effort.
+		_, _ = c.Process.Wait() // Best effort.
+	})
+	return e
+}
+
+func expect(t *testing.T, e *Expecter, patterns ...Pattern) *Match {
+	t.Helper()
+
+	m, err := e.Expect(context.Background(), patterns...)
+	if err != nil {
+		t.Fatalf("Unexpected error from Expect: %s.", err)
+	}
+	return m
+}
+
+func TestExpectCat(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("cat"))
+
+	if err := e.SendLine("hello"); err != nil {
+		t.Fatalf("Unexpected error from SendLine: %s.", err)
+	}
+	// Once echoed by the tty, then by cat.
+	m := expect(t, e, RegexpString(`hello\r\nhello\r\n`))
+	if m.Index != 0 || m.Before != "" {
+		t.Errorf("Unexpected match: %+v.", m)
+	}
+}
+
+func TestExpectGroups(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("sh", "-c", `echo "version 1.2"; read x; echo "got $x"`))
+
+	m := expect(t, e, RegexpString(`version (\d+)\.(\d+)`))
+	if len(m.Groups) != 3 || m.Groups[1] != "1" || m.Groups[2] != "2" {
+		t.Errorf("Unexpected groups: %q.", m.Groups)
+	}
+
+	if err := e.SendLine("yes"); err != nil {
+		t.Fatalf("Unexpected error from SendLine: %s.", err)
+	}
+	m = expect(t, e, String("never"), RegexpString(`got (\w+)`))
+	if m.Index != 1 || m.Groups[1] != "yes" {
+		t.Errorf("Unexpected match: %+v.", m)
+	}
+}
+
+func TestExpectEOF(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("sh", "-c", "echo bye"))
+
+	m := expect(t, e, String("never"), EOF())
+	if m.Index != 1 || m.Groups[0] != "bye\r\n" {
+		t.Errorf("Unexpected match: %+v.", m)
+	}
+
+	if _, err := e.Expect(context.Background(), String("never")); !errors.Is(err, ErrEOF) {
+		t.Errorf("Unexpected error from Expect after EOF: %v.", err)
+	}
+}
+
+func TestExpectTimeout(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("cat"), WithTimeout(50*time.Millisecond))
+
+	if _, err := e.Expect(context.Background(), String("never")); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Unexpected error from Expect: %v.", err)
+	}
+}
+
+func TestExpectContext(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("cat"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := e.Expect(ctx, String("never")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error from Expect: %v.", err)
+	}
+}
+
+func TestExpectBufferSize(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("sh", "-c", "printf 0123456789"), WithBufferSize(4))
+
+	m := expect(t, e, EOF())
+	if m.Groups[0] != "6789" {
+		t.Errorf("Unexpected buffer: %q.", m.Groups[0])
+	}
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	e := spawn(t, exec.Command("cat"))
+
+	// The command is still running, with the tty open.
+	done := make(chan error, 1)
+	go func() { done <- e.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unexpected error from Close: %s.", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return.")
+	}
+}
@@ -0,0 +1,63 @@
+package expect
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Pattern describes the output expected by Expect.
+type Pattern interface {
+	// match returns the location of the match in b and of its groups,
+	// as (*regexp.Regexp).FindSubmatchIndex, or nil if there is none.
+	// eof tells whether b is the end of the output.
+	match(b []byte, eof bool) []int
+}
+
+type regexpPattern struct {
+	re *regexp.Regexp
+}
+
+func (p regexpPattern) match(b []byte, _ bool) []int {
+	return p.re.FindSubmatchIndex(b)
+}
+
+// Regexp matches the output against re. Captured groups are reported in
+// Match.Groups.
+func Regexp(re *regexp.Regexp) Pattern {
+	return regexpPattern{re: re}
+}
+
+// RegexpString is like Regexp but compiles expr, panicking on error.
+func RegexpString(expr string) Pattern {
+	return regexpPattern{re: regexp.MustCompile(expr)}
+}
+
+type stringPattern string
+
+func (p stringPattern) match(b []byte, _ bool) []int {
+	i := bytes.Index(b, []byte(p))
+	if i < 0 {
+		return nil
+	}
+	return []int{i, i + len(p)}
+}
+
+// String matches the first occurrence of s in the output.
+func String(s string) Pattern {
+	return stringPattern(s)
+}
+
+type eofPattern struct{}
+
+func (eofPattern) match(b []byte, eof bool) []int {
+	if !eof {
+		return nil
+	}
+	return []int{0, len(b)}
+}
+
+// EOF matches the end of the output, usually when the command exits.
+// Match.Groups[0] holds the remaining output.
+func EOF() Pattern {
+	return eofPattern{}
+}
@@ -0,0 +1,81 @@
+package pty
+
+import (
+	"os"
+)
+
+// ExtProcReader reads from a pty in packet mode with extended processing
+// (EXTPROC) enabled. It returns the data written to the tty and notifies the
+// changes of the terminal attributes, so that line editing can be done on the
+// pty side while the tty is in canonical mode, like telnet LINEMODE.
+type ExtProcReader struct {
+	pty     *os.File
+	packets *PacketReader
+	changes chan *Termios
+	data    []byte // Unread data of the last packet.
+}
+
+// NewExtProcReader enables packet mode and extended processing on pty and
+// returns a reader for it. The current terminal attributes are notified right away.
+func NewExtProcReader(pty *os.File) (*ExtProcReader, error) {
+	if err := SetPacketMode(pty, true); err != nil {
+		return nil, err
+	}
+	if err := SetExtProc(pty, true); err != nil {
+		return nil, err
+	}
+
+	r := &ExtProcReader{
+		pty:     pty,
+		packets: NewPacketReader(pty),
+		changes: make(chan *Termios, 1),
+	}
+	if err := r.notify(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Changes returns a channel receiving the terminal attributes whenever they change.
+//
+// Changes are detected while reading, so Read must be called for notifications
+// to be delivered. Only the latest attributes are kept until received.
+func (r *ExtProcReader) Changes() <-chan *Termios {
+	return r.changes
+}
+
+// Read reads the data written to the tty. Status packets other than
+// attribute changes are discarded.
+func (r *ExtProcReader) Read(b []byte) (int, error) {
+	for len(r.data) == 0 {
+		p, err := r.packets.ReadPacket()
+		if err != nil {
+			return 0, err
+		}
+		if p.Status&PacketIoctl != 0 {
+			if err := r.notify(); err != nil {
+				return 0, err
+			}
+		}
+		r.data = p.Data
+	}
+
+	n := copy(b, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// notify sends the current terminal attributes, replacing the ones not received yet.
+func (r *ExtProcReader) notify() error {
+	attr, err := GetAttr(r.pty)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-r.changes: // Drop the stale attributes.
+	default:
+	}
+	r.changes <- attr
+	return nil
+}
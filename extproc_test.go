@@ -0,0 +1,48 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd
+// +build linux darwin freebsd dragonfly netbsd openbsd
+
+package pty
+
+import (
+	"testing"
+	"time"
+)
+
+// nextTermios returns the next terminal attributes notified by r.
+func nextTermios(t *testing.T, r *ExtProcReader) *Termios {
+	t.Helper()
+
+	select {
+	case attr := <-r.Changes():
+		return attr
+	case <-time.After(time.Second):
+		t.Fatal("Terminal attributes change was not notified.")
+		return nil
+	}
+}
+
+func TestExtProcReader(t *testing.T) {
+	t.Parallel()
+
+	pty, tty := openClose(t)
+
+	r, err := NewExtProcReader(pty)
+	noError(t, err, "Unexpected error from NewExtProcReader")
+
+	attr := nextTermios(t, r)
+	assert(t, true, attr.Lflag&EXTPROC != 0, "Expected EXTPROC to be enabled")
+	assert(t, true, attr.Lflag&ICANON != 0, "Expected ICANON to be enabled by default")
+
+	// The child switches to non-canonical mode.
+	_, err = MakeCbreak(tty)
+	noError(t, err, "Unexpected error from MakeCbreak")
+
+	_, err = tty.Write([]byte("ping"))
+	noError(t, err, "Unexpected error from tty Write")
+
+	buffer := readN(t, r, 4, "Unexpected error from ExtProcReader Read")
+	assertBytes(t, []byte("ping"), buffer, "Unexpected result returned from ExtProcReader Read")
+
+	attr = nextTermios(t, r)
+	assert(t, true, attr.Lflag&ICANON == 0, "Expected ICANON to be disabled")
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd
+// +build linux darwin freebsd dragonfly netbsd openbsd
+
+package pty
+
+import (
+	"os"
+)
+
+// SetExtProc enables or disables extended processing (EXTPROC) on pty.
+//
+// With extended processing, the line editing is expected to be done on the pty
+// side. Combined with packet mode, changes of the terminal attributes are
+// reported with PacketIoctl, see ExtProcReader.
+func SetExtProc(pty *os.File, enable bool) error {
+	attr, err := GetAttr(pty)
+	if err != nil {
+		return err
+	}
+
+	if enable {
+		attr.Lflag |= EXTPROC
+	} else {
+		attr.Lflag &^= EXTPROC
+	}
+	return SetAttr(pty, TCSANOW, attr)
+}
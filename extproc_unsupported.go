@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!dragonfly,!netbsd,!openbsd
+
+package pty
+
+import (
+	"os"
+)
+
+// SetExtProc enables or disables extended processing (EXTPROC) on pty.
+func SetExtProc(*os.File, bool) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,14 @@
+package pty
+
+import "errors"
+
+// ErrNoPty is returned by RecvPty when the message received does not carry
+// a file descriptor.
+var ErrNoPty = errors.New("no pty received")
+
+// PtyMeta describes a pty passed to another process, see SendPty.
+type PtyMeta struct {
+	Name string  // Name of the tty, e.g. /dev/pts/0.
+	Size Winsize // Size of the terminal.
+	Pid  int     // Process ID of the command, 0 if none.
+}
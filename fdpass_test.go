@@ -0,0 +1,132 @@
+//go:build !windows && go1.12
+// +build !windows,go1.12
+
+package pty
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRecvPtyHelper receives the pty in the process started by
+// TestSendPty.
+func TestRecvPtyHelper(t *testing.T) {
+	path := os.Getenv("PTY_TEST_SOCKET")
+	if path == "" {
+		t.Skip("Helper process of TestSendPty.")
+	}
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	noError(t, err, "Unexpected error from Dial")
+	defer func() { _ = conn.Close() }() // Best effort.
+
+	master, meta, err := RecvPty(conn)
+	noError(t, err, "Unexpected error from RecvPty")
+	defer func() { _ = master.Close() }() // Best effort.
+	assert(t, fmt.Sprintf("%s %d %dx%d", meta.Name, meta.Pid, meta.Size.Cols, meta.Size.Rows), os.Getenv("PTY_TEST_META"), "Unexpected meta")
+
+	ws, err := GetsizeFull(master)
+	noError(t, err, "Unexpected error from GetsizeFull")
+	assert(t, *ws, meta.Size, "Unexpected size of the pty received")
+
+	// The output written before the handover has not been read.
+	_, err = master.Write([]byte("after\n"))
+	noError(t, err, "Unexpected error from Write")
+	out := readN(t, master, 30, "Unexpected error reading the output")
+	assert(t, string(out), "before\r\nbefore\r\nafter\r\nafter\r\n", "Unexpected output")
+}
+
+func TestSendPty(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pty.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	noError(t, err, "Unexpected error from Listen")
+	defer func() { _ = l.Close() }() // Best effort.
+
+	ws := &Winsize{Rows: 10, Cols: 40}
+	cmd := exec.Command("cat")
+	p, err := StartPty(cmd, ws)
+	noError(t, err, "Unexpected error from StartPty")
+	defer func() {
+		_ = p.Close()          // Best effort.
+		_ = cmd.Process.Kill() // Best effort.
+		_ = cmd.Wait()         // Best effort.
+	}()
+	_, err = p.Write([]byte("before\n"))
+	noError(t, err, "Unexpected error from Write")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	helper := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestRecvPtyHelper$", "-test.v") //nolint:gosec // Expected test binary.
+	helper.Env = append(os.Environ(),
+		"PTY_TEST_SOCKET="+path,
+		fmt.Sprintf("PTY_TEST_META=%s %d %dx%d", p.Name(), cmd.Process.Pid, ws.Cols, ws.Rows))
+	out := make(chan []byte, 1)
+	go func() {
+		b, _ := helper.CombinedOutput() // The result is in the output.
+		out <- b
+	}()
+
+	conn, err := l.AcceptUnix()
+	noError(t, err, "Unexpected error from Accept")
+	defer func() { _ = conn.Close() }() // Best effort.
+	err = SendPty(conn, p.Master(), &PtyMeta{Name: p.Name(), Size: *ws, Pid: cmd.Process.Pid})
+	noError(t, err, "Unexpected error from SendPty")
+
+	// The session goes on in the helper once the pty is closed here.
+	noError(t, p.Master().Close(), "Unexpected error from Close")
+	b := <-out
+	if helper.ProcessState == nil || !helper.ProcessState.Success() || !bytes.Contains(b, []byte("--- PASS: TestRecvPtyHelper")) {
+		t.Fatalf("Unexpected failure of the helper process:\n%s", b)
+	}
+}
+
+func TestRecvPtyNoFd(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pty.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	noError(t, err, "Unexpected error from Listen")
+	defer func() { _ = l.Close() }() // Best effort.
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	noError(t, err, "Unexpected error from Dial")
+	defer func() { _ = conn.Close() }() // Best effort.
+
+	// A message without a pty, received in parts, then a pty.
+	pty, tty, err := Open()
+	noError(t, err, "Unexpected error from Open")
+	defer func() { _, _ = pty.Close(), tty.Close() }() // Best effort.
+	_, err = conn.Write(make([]byte, 10))
+	noError(t, err, "Unexpected error from Write")
+	errc := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := conn.Write(make([]byte, ptyMetaSize-10)); err != nil {
+			errc <- err
+			return
+		}
+		errc <- SendPty(conn, pty, &PtyMeta{Name: tty.Name(), Pid: 1})
+	}()
+
+	peer, err := l.AcceptUnix()
+	noError(t, err, "Unexpected error from Accept")
+	defer func() { _ = peer.Close() }() // Best effort.
+	if _, _, err := RecvPty(peer); !errors.Is(err, ErrNoPty) {
+		t.Errorf("Unexpected error from RecvPty without a pty: %v.", err)
+	}
+	master, meta, err := RecvPty(peer)
+	noError(t, err, "Unexpected error from RecvPty after a message without a pty")
+	defer func() { _ = master.Close() }() // Best effort.
+	assert(t, tty.Name()+" 1", fmt.Sprintf("%s %d", meta.Name, meta.Pid), "Unexpected meta")
+	noError(t, <-errc, "Unexpected error sending")
+}
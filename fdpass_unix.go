@@ -0,0 +1,116 @@
+//go:build !windows && go1.12
+// +build !windows,go1.12
+
+package pty
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// ptyMetaSize is the size of the message sent with the pty: the size, the
+// pid, the length of the name and the name, padded. The size is fixed so
+// the message can be read whole from datagram sockets without reading past
+// it on stream sockets.
+const ptyMetaSize = 8 + 4 + 1 + 255
+
+// SendPty sends the pty master and its metadata over conn, as done when
+// handing a live pty over to another process, e.g. for a restart without
+// downtime. The file descriptor is passed with SCM_RIGHTS. The master stays
+// open in the calling process, which usually closes it once received, see
+// RecvPty.
+func SendPty(conn *net.UnixConn, master *os.File, meta *PtyMeta) error {
+	if len(meta.Name) > 255 {
+		return fmt.Errorf("tty name too long: %q", meta.Name)
+	}
+	b := make([]byte, ptyMetaSize)
+	binary.BigEndian.PutUint16(b[0:], meta.Size.Rows)
+	binary.BigEndian.PutUint16(b[2:], meta.Size.Cols)
+	binary.BigEndian.PutUint16(b[4:], meta.Size.X)
+	binary.BigEndian.PutUint16(b[6:], meta.Size.Y)
+	binary.BigEndian.PutUint32(b[8:], uint32(meta.Pid))
+	b[12] = byte(len(meta.Name))
+	copy(b[13:], meta.Name)
+
+	// Control does not put the master in blocking mode, unlike Fd.
+	sc, err := master.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var werr error
+	if err := sc.Control(func(fd uintptr) {
+		_, _, werr = conn.WriteMsgUnix(b, syscall.UnixRights(int(fd)), nil)
+	}); err != nil {
+		return err
+	}
+	return werr
+}
+
+// RecvPty receives a pty master and its metadata sent over conn with
+// SendPty. The master shares its state with the one sent: the tty, its
+// settings and the unread output.
+func RecvPty(conn *net.UnixConn) (*os.File, *PtyMeta, error) {
+	b := make([]byte, ptyMetaSize)
+	oob := make([]byte, syscall.CmsgSpace(4*4)) // Room for extra descriptors, to close them.
+	n, oobn, flags, _, err := conn.ReadMsgUnix(b, oob)
+	if err != nil {
+		return nil, nil, err
+	}
+	fds, err := parseRights(oob[:oobn])
+	if err != nil {
+		return nil, nil, err
+	}
+	// On stream sockets, the message may be received in parts. It is read
+	// whole even without a pty, so the next message can be received.
+	if n < len(b) {
+		if _, err := io.ReadFull(conn, b[n:]); err != nil {
+			closeFds(fds)
+			return nil, nil, err
+		}
+	}
+	if flags&syscall.MSG_CTRUNC != 0 || len(fds) != 1 {
+		closeFds(fds)
+		return nil, nil, ErrNoPty
+	}
+	syscall.CloseOnExec(fds[0])
+	master := os.NewFile(uintptr(fds[0]), "/dev/ptmx")
+
+	meta := &PtyMeta{
+		Size: Winsize{
+			Rows: binary.BigEndian.Uint16(b[0:]),
+			Cols: binary.BigEndian.Uint16(b[2:]),
+			X:    binary.BigEndian.Uint16(b[4:]),
+			Y:    binary.BigEndian.Uint16(b[6:]),
+		},
+		Pid:  int(binary.BigEndian.Uint32(b[8:])),
+		Name: string(b[13 : 13+int(b[12])]),
+	}
+	return master, meta, nil
+}
+
+// closeFds closes the file descriptors fds.
+func closeFds(fds []int) {
+	for _, fd := range fds {
+		_ = syscall.Close(fd) // Best effort.
+	}
+}
+
+// parseRights returns the file descriptors of the SCM_RIGHTS messages of
+// oob.
+func parseRights(oob []byte) ([]int, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+	var fds []int
+	for i := range msgs {
+		if rights, err := syscall.ParseUnixRights(&msgs[i]); err == nil {
+			fds = append(fds, rights...)
+		}
+	}
+	return fds, nil
+}
@@ -0,0 +1,19 @@
+//go:build windows || !go1.12
+// +build windows !go1.12
+
+package pty
+
+import (
+	"net"
+	"os"
+)
+
+// SendPty sends the pty master and its metadata over conn.
+func SendPty(*net.UnixConn, *os.File, *PtyMeta) error {
+	return ErrUnsupported
+}
+
+// RecvPty receives a pty master and its metadata sent over conn with SendPty.
+func RecvPty(*net.UnixConn) (*os.File, *PtyMeta, error) {
+	return nil, nil, ErrUnsupported
+}
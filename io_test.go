@@ -85,6 +85,96 @@ func TestReadClose(t *testing.T) {
 	}
 }
 
+// Check that SetDeadline() works for a non-blocking ptmx
+// without having to touch (*os.File).Fd().
+func TestReadDeadlineNonBlocking(t *testing.T) {
+	t.Parallel()
+
+	ptmx, success := prepareNonBlocking(t)
+
+	noError(t, ptmx.SetDeadline(time.Now().Add(timeout/10)), "Error: set deadline")
+
+	buf := make([]byte, 1)
+	n, err := ptmx.Read(buf)
+	success()
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Unexpected read error: %v.", err)
+	}
+	assert(t, 0, n, "Unexpected read count")
+}
+
+// Check that Close() interrupts outstanding Read() calls on a non-blocking ptmx
+// without having to touch (*os.File).Fd().
+func TestReadCloseNonBlocking(t *testing.T) {
+	t.Parallel()
+
+	ptmx, success := prepareNonBlocking(t)
+
+	go func() {
+		time.Sleep(timeout / 10)
+		if err := ptmx.Close(); err != nil {
+			t.Errorf("Failed to close ptmx: %s.", err)
+		}
+	}()
+
+	buf := make([]byte, 1)
+	n, err := ptmx.Read(buf)
+	success()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Unexpected read error: %v.", err)
+	}
+	assert(t, 0, n, "Unexpected read count")
+}
+
+// Check that ioctls such as Setsize() don't put a non-blocking ptmx back
+// in blocking mode, which would prevent deadlines from working.
+func TestReadDeadlineAfterResize(t *testing.T) {
+	t.Parallel()
+
+	ptmx, success := prepareNonBlocking(t)
+
+	for i := uint16(1); i <= 10; i++ {
+		noError(t, Setsize(ptmx, &Winsize{Rows: i, Cols: i}), "Error: set size")
+		_, err := GetsizeFull(ptmx)
+		noError(t, err, "Error: get size")
+	}
+
+	noError(t, ptmx.SetDeadline(time.Now().Add(timeout/10)), "Error: set deadline")
+
+	buf := make([]byte, 1)
+	n, err := ptmx.Read(buf)
+	success()
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Unexpected read error: %v.", err)
+	}
+	assert(t, 0, n, "Unexpected read count")
+}
+
+// Check that Open() returns a blocking ptmx, as it did when the ioctls used
+// (*os.File).Fd(): deadlines are not honored.
+func TestReadDeadlineBlocking(t *testing.T) {
+	t.Parallel()
+
+	ptmx, pts, err := Open()
+	noError(t, err, "Error: open")
+	defer func() { _, _ = ptmx.Close(), pts.Close() }() // Best effort.
+	_, err = GetsizeFull(ptmx)
+	noError(t, err, "Error: get size")
+
+	if err := ptmx.SetDeadline(time.Now().Add(timeout / 10)); err != nil && !errors.Is(err, os.ErrNoDeadline) {
+		t.Fatalf("Error: set deadline: %s.", err)
+	}
+	go func() {
+		time.Sleep(timeout / 5)
+		_, _ = pts.Write([]byte{errMarker}) // Best effort, unblock ptmx.Read().
+	}()
+
+	buf := make([]byte, 1)
+	n, err := ptmx.Read(buf)
+	noError(t, err, "Unexpected read error")
+	assertBytes(t, []byte{errMarker}, buf[:n], "Unexpected data read")
+}
+
 // Open pty and setup watchdogs for graceful and not so graceful failure modes.
 func prepare(t *testing.T) (ptmx *os.File, done func()) {
 	t.Helper()
@@ -106,6 +196,27 @@ func prepare(t *testing.T) (ptmx *os.File, done func()) {
 	if err != nil {
 		t.Fatalf("Error: open: %s.\n", err)
 	}
+	return ptmx, watchdog(t, ptmx, pts)
+}
+
+// Open a non-blocking pty and setup watchdogs, see prepare.
+func prepareNonBlocking(t *testing.T) (ptmx *os.File, done func()) {
+	t.Helper()
+
+	ptmx, pts, err := OpenWithOptions(OpenOptions{NonBlocking: true})
+	if errors.Is(err, ErrUnsupported) {
+		t.Skipf("Non-blocking mode is not supported on %s.", runtime.GOOS)
+	}
+	if err != nil {
+		t.Fatalf("Error: open: %s.\n", err)
+	}
+	return ptmx, watchdog(t, ptmx, pts)
+}
+
+// Setup watchdogs for graceful and not so graceful failure modes.
+func watchdog(t *testing.T, ptmx, pts *os.File) (done func()) {
+	t.Helper()
+
 	t.Cleanup(func() { _ = ptmx.Close() })
 	t.Cleanup(func() { _ = pts.Close() })
 
@@ -132,5 +243,5 @@ func prepare(t *testing.T) (ptmx *os.File, done func()) {
 		}
 	}()
 
-	return ptmx, done
+	return done
 }
@@ -5,12 +5,9 @@ package pty
 
 import "os"
 
+// ioctl calls ioctlInner through (*os.File).SyscallConn() rather than
+// (*os.File).Fd(), which would put the file in blocking mode.
 func ioctl(f *os.File, cmd, ptr uintptr) error {
-	return ioctlInner(f.Fd(), cmd, ptr) // Fall back to blocking io.
-}
-
-// NOTE: Unused. Keeping for reference.
-func ioctlNonblock(f *os.File, cmd, ptr uintptr) error {
 	sc, e := f.SyscallConn()
 	if e != nil {
 		return ioctlInner(f.Fd(), cmd, ptr) // Fall back to blocking io (old behavior).
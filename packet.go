@@ -0,0 +1,60 @@
+package pty
+
+import (
+	"io"
+)
+
+// PacketStatus is the status byte reported by a pty in packet mode.
+// It is a bitmask of the Packet* flags, zero for data packets.
+type PacketStatus uint8
+
+// Packet mode status flags, see TIOCPKT in ioctl_tty(2).
+const (
+	PacketFlushRead  PacketStatus = 0x01 // TIOCPKT_FLUSHREAD: the read queue of the tty was flushed.
+	PacketFlushWrite PacketStatus = 0x02 // TIOCPKT_FLUSHWRITE: the write queue of the tty was flushed.
+	PacketStop       PacketStatus = 0x04 // TIOCPKT_STOP: output to the tty was stopped (^S).
+	PacketStart      PacketStatus = 0x08 // TIOCPKT_START: output to the tty was restarted (^Q).
+	PacketNoStop     PacketStatus = 0x10 // TIOCPKT_NOSTOP: the stop and start characters are no longer ^S/^Q.
+	PacketDoStop     PacketStatus = 0x20 // TIOCPKT_DOSTOP: the stop and start characters are ^S/^Q.
+	PacketIoctl      PacketStatus = 0x40 // TIOCPKT_IOCTL: the terminal attributes changed, see SetExtProc.
+)
+
+// Packet is a single read from a pty in packet mode: either data written
+// to the tty, or a status change with no data.
+type Packet struct {
+	Status PacketStatus
+	Data   []byte
+}
+
+// IsData reports whether the packet carries data rather than a status change.
+func (p Packet) IsData() bool {
+	return p.Status == 0
+}
+
+// PacketReader reads packets from a pty in packet mode, see SetPacketMode.
+type PacketReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewPacketReader returns a PacketReader reading from pty.
+func NewPacketReader(pty io.Reader) *PacketReader {
+	return &PacketReader{r: pty, buf: make([]byte, 32*1024)}
+}
+
+// ReadPacket reads the next packet.
+func (r *PacketReader) ReadPacket() (Packet, error) {
+	for {
+		n, err := r.r.Read(r.buf)
+		if n > 0 {
+			p := Packet{Status: PacketStatus(r.buf[0])}
+			if p.IsData() {
+				p.Data = append([]byte(nil), r.buf[1:n]...)
+			}
+			return p, nil
+		}
+		if err != nil {
+			return Packet{}, err
+		}
+	}
+}
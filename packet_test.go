@@ -0,0 +1,56 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd
+// +build linux darwin freebsd dragonfly netbsd openbsd
+
+package pty
+
+import (
+	"testing"
+)
+
+func TestPacketReader(t *testing.T) {
+	t.Parallel()
+
+	pty, tty := openClose(t)
+
+	noError(t, SetPacketMode(pty, true), "Unexpected error from SetPacketMode")
+	r := NewPacketReader(pty)
+
+	// Data written to the tty is prefixed with TIOCPKT_DATA.
+	_, err := tty.Write([]byte("ping"))
+	noError(t, err, "Unexpected error from tty Write")
+
+	p, err := r.ReadPacket()
+	noError(t, err, "Unexpected error from ReadPacket")
+	assert(t, true, p.IsData(), "Expected a data packet")
+	assertBytes(t, []byte("ping"), p.Data, "Unexpected packet data")
+
+	// Disabling IXON is reported as TIOCPKT_NOSTOP.
+	attr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+	if attr.Iflag&IXON == 0 {
+		t.Skip("IXON not set by default.")
+	}
+	attr.Iflag &^= IXON
+	noError(t, SetAttr(tty, TCSANOW, attr), "Unexpected error from SetAttr")
+
+	p, err = r.ReadPacket()
+	noError(t, err, "Unexpected error from ReadPacket")
+	assert(t, false, p.IsData(), "Expected a status packet")
+	assert(t, PacketNoStop, p.Status&PacketNoStop, "Expected TIOCPKT_NOSTOP status")
+	assert(t, 0, len(p.Data), "Unexpected data in status packet")
+}
+
+func TestPacketModeDisabled(t *testing.T) {
+	t.Parallel()
+
+	pty, tty := openClose(t)
+
+	noError(t, SetPacketMode(pty, true), "Unexpected error from SetPacketMode enable")
+	noError(t, SetPacketMode(pty, false), "Unexpected error from SetPacketMode disable")
+
+	_, err := tty.Write([]byte("ping"))
+	noError(t, err, "Unexpected error from tty Write")
+
+	buffer := readN(t, pty, 4, "Unexpected error from pty Read")
+	assertBytes(t, []byte("ping"), buffer, "Unexpected result returned from pty Read")
+}
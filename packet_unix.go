@@ -0,0 +1,22 @@
+//go:build !windows && !solaris
+// +build !windows,!solaris
+
+package pty
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// SetPacketMode enables or disables packet mode on pty. In packet mode, each
+// read from pty starts with a status byte, see PacketReader.
+func SetPacketMode(pty *os.File, enable bool) error {
+	var v _C_int
+	if enable {
+		v = 1
+	}
+
+	//nolint:gosec // Expected unsafe pointer for Syscall call.
+	return ioctl(pty, syscall.TIOCPKT, uintptr(unsafe.Pointer(&v)))
+}
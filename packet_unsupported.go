@@ -0,0 +1,13 @@
+//go:build windows || solaris
+// +build windows solaris
+
+package pty
+
+import (
+	"os"
+)
+
+// SetPacketMode enables or disables packet mode on pty.
+func SetPacketMode(*os.File, bool) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+// +build darwin
+
+package pty
+
+import (
+	"os"
+	"syscall"
+)
+
+// There is no TIOCGSID on darwin, use the session of the foreground process group instead.
+func tcgetsid(t *os.File) (int, error) {
+	pgrp, err := ForegroundPgrp(t)
+	if err != nil {
+		return 0, err
+	}
+	return syscall.Getsid(pgrp)
+}
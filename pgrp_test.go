@@ -0,0 +1,131 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd || solaris
+// +build linux darwin freebsd dragonfly netbsd openbsd solaris
+
+package pty
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestForegroundPgrp(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("sh", "-c", "sleep 5")
+	p := startPtyCleanup(t, c)
+
+	pgrp, err := ForegroundPgrp(p.Master())
+	noError(t, err, "Unexpected error from ForegroundPgrp")
+	assert(t, c.Process.Pid, pgrp, "Unexpected foreground process group")
+
+	sid, err := SessionID(p.Master())
+	noError(t, err, "Unexpected error from SessionID")
+	assert(t, c.Process.Pid, sid, "Unexpected session ID")
+}
+
+func TestForegroundProcess(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("sh", "-c", "exec sleep 5")
+	p := startPtyCleanup(t, c)
+
+	// Wait for the shell to exec sleep in the foreground.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		proc, err := ForegroundProcess(p.Master())
+		if errors.Is(err, ErrUnsupported) {
+			t.Skip("ForegroundProcess is not supported.")
+		}
+		noError(t, err, "Unexpected error from ForegroundProcess")
+		assert(t, c.Process.Pid, proc.Pgrp, "Unexpected foreground process group")
+
+		if proc.Name == "sleep" {
+			assert(t, "sleep 5", strings.Join(proc.Args, " "), "Unexpected foreground process args")
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Foreground process did not change to sleep: %+v.", proc)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestForegroundProcessLeader(t *testing.T) {
+	t.Parallel()
+
+	// Without job control, the background sleep is in the foreground
+	// process group, but started after its leader.
+	c := exec.Command("sh", "-c", "sleep 5 & sleep 0.1; exec sleep 6")
+	p := startPtyCleanup(t, c)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		proc, err := ForegroundProcess(p.Master())
+		if errors.Is(err, ErrUnsupported) {
+			t.Skip("ForegroundProcess is not supported.")
+		}
+		noError(t, err, "Unexpected error from ForegroundProcess")
+
+		if proc.Name == "sleep" {
+			assert(t, c.Process.Pid, proc.Pid, "Unexpected foreground process")
+			assert(t, "sleep 6", strings.Join(proc.Args, " "), "Unexpected foreground process args")
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Foreground process did not change to sleep: %+v.", proc)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startNoSig starts cat with ISIG disabled, so control characters don't generate signals.
+func startNoSig(t *testing.T) (*Pty, *exec.Cmd) {
+	t.Helper()
+
+	c := exec.Command("cat")
+	p := startPtyCleanup(t, c)
+
+	attr, err := GetAttr(p.Master())
+	noError(t, err, "Unexpected error from GetAttr")
+	attr.Lflag &^= ISIG
+	noError(t, SetAttr(p.Master(), TCSANOW, attr), "Unexpected error from SetAttr")
+
+	return p, c
+}
+
+// assertSignaled waits for c and asserts it was terminated by sig.
+func assertSignaled(t *testing.T, c *exec.Cmd, sig syscall.Signal) {
+	t.Helper()
+
+	var exitErr *exec.ExitError
+	if err := c.Wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("Expected an exit error, got: %v.", err)
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		t.Fatalf("Expected the command to be signaled: %s.", exitErr)
+	}
+	assert(t, sig, status.Signal(), "Unexpected signal")
+}
+
+func TestSignalForeground(t *testing.T) {
+	t.Parallel()
+
+	p, c := startNoSig(t)
+
+	noError(t, p.SignalForeground(syscall.SIGINT), "Unexpected error from SignalForeground")
+	assertSignaled(t, c, syscall.SIGINT)
+}
+
+func TestSignalForegroundKill(t *testing.T) {
+	t.Parallel()
+
+	p, c := startNoSig(t)
+
+	noError(t, killForeground(p.Master(), syscall.SIGTERM), "Unexpected error from killForeground")
+	assertSignaled(t, c, syscall.SIGTERM)
+}
@@ -0,0 +1,20 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package pty
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func tcgetsid(t *os.File) (int, error) {
+	var sid _C_int
+
+	//nolint:gosec // Expected unsafe pointer for Syscall call.
+	if err := ioctl(t, syscall.TIOCGSID, uintptr(unsafe.Pointer(&sid))); err != nil {
+		return 0, err
+	}
+	return int(sid), nil
+}
@@ -0,0 +1,72 @@
+//go:build !windows
+// +build !windows
+
+package pty
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ForegroundPgrp returns the foreground process group of the terminal t,
+// see tcgetpgrp(3).
+//
+// When t is a pty, the foreground process group of its tty is returned.
+func ForegroundPgrp(t *os.File) (int, error) {
+	var pgrp _C_int
+
+	//nolint:gosec // Expected unsafe pointer for Syscall call.
+	if err := ioctl(t, syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&pgrp))); err != nil {
+		return 0, err
+	}
+	return int(pgrp), nil
+}
+
+// SessionID returns the session ID of the terminal t, i.e. the process group
+// of the session leader, see tcgetsid(3).
+//
+// When t is a pty, the session of its tty is returned.
+func SessionID(t *os.File) (int, error) {
+	return tcgetsid(t)
+}
+
+// SignalForeground sends sig to the foreground process group of the terminal
+// t, regardless of its termios settings, unlike writing the VINTR character
+// to the pty.
+//
+// On Linux, TIOCSIG is used when t is a pty, otherwise the process group
+// returned by ForegroundPgrp is signaled.
+func SignalForeground(t *os.File, sig syscall.Signal) error {
+	if err := tiocsig(t, sig); err == nil {
+		return nil
+	}
+	return killForeground(t, sig)
+}
+
+// killForeground sends sig to the foreground process group of t with kill(2).
+func killForeground(t *os.File, sig syscall.Signal) error {
+	pgrp, err := ForegroundPgrp(t)
+	if err != nil {
+		return err
+	}
+	if pgrp <= 0 {
+		return syscall.ESRCH
+	}
+	return syscall.Kill(-pgrp, sig)
+}
+
+// signalSession sends sig to the process group of the command, which is
+// the session leader, and to the foreground process group of the terminal.
+func (p *Pty) signalSession(sig syscall.Signal) error {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return ErrNoCommand
+	}
+	pid := p.cmd.Process.Pid
+
+	err := syscall.Kill(-pid, sig)
+	if pgrp, e := ForegroundPgrp(p.master); e == nil && pgrp > 0 && pgrp != pid {
+		_ = syscall.Kill(-pgrp, sig) // Best effort.
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package pty
+
+import (
+	"os"
+	"syscall"
+)
+
+// ForegroundPgrp returns the foreground process group of the terminal t.
+func ForegroundPgrp(*os.File) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// SessionID returns the session ID of the terminal t.
+func SessionID(*os.File) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// SignalForeground sends sig to the foreground process group of the terminal t.
+func SignalForeground(*os.File, syscall.Signal) error {
+	return ErrUnsupported
+}
+
+func (p *Pty) signalSession(syscall.Signal) error {
+	return ErrUnsupported
+}
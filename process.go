@@ -0,0 +1,9 @@
+package pty
+
+// Process describes a process attached to a terminal, see ForegroundProcess.
+type Process struct {
+	Pid  int
+	Pgrp int      // Process group.
+	Name string   // Command name, e.g. "vim".
+	Args []string // Command line, including the program name.
+}
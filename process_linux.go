@@ -0,0 +1,98 @@
+//go:build linux && go1.16
+// +build linux,go1.16
+
+package pty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ForegroundProcess returns the process running in the foreground of the
+// terminal t, i.e. the leader of its foreground process group, e.g. the
+// command being run by a shell. If the leader exited, it returns the most
+// recently started process of the group instead. It relies on /proc.
+func ForegroundProcess(t *os.File) (*Process, error) {
+	pgrp, err := ForegroundPgrp(t)
+	if err != nil {
+		return nil, err
+	}
+	if pgrp <= 0 {
+		return nil, fmt.Errorf("no foreground process group: %w", os.ErrNotExist)
+	}
+
+	// The ID of a process group is the PID of its leader.
+	if p, _, err := readProcess(pgrp); err == nil && p.Pgrp == pgrp {
+		return p, nil
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var (
+		fg      *Process
+		fgStart uint64
+	)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		p, start, err := readProcess(pid)
+		if err != nil || p.Pgrp != pgrp {
+			continue // The process may have exited in the meantime.
+		}
+		if fg == nil || start > fgStart || (start == fgStart && p.Pid > fg.Pid) {
+			fg, fgStart = p, start
+		}
+	}
+	if fg == nil {
+		return nil, fmt.Errorf("no process in group %d: %w", pgrp, os.ErrNotExist)
+	}
+	return fg, nil
+}
+
+// readProcess reads the description of process pid and its start time from /proc.
+func readProcess(pid int) (*Process, uint64, error) {
+	dir := "/proc/" + strconv.Itoa(pid)
+
+	stat, err := os.ReadFile(dir + "/stat")
+	if err != nil {
+		return nil, 0, err
+	}
+	// The command name is between parenthesis and may contain any character,
+	// see proc(5). Fields after the name start with state, ppid and pgrp,
+	// the start time is the 22nd field of the file.
+	nameStart, nameEnd := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')')
+	if nameStart < 0 || nameEnd < nameStart {
+		return nil, 0, errors.New("malformed " + dir + "/stat")
+	}
+	fields := strings.Fields(string(stat[nameEnd+1:]))
+	if len(fields) < 20 {
+		return nil, 0, errors.New("malformed " + dir + "/stat")
+	}
+	pgrp, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, 0, err
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cmdline, err := os.ReadFile(dir + "/cmdline")
+	if err != nil {
+		return nil, 0, err
+	}
+	var args []string
+	if cmdline = bytes.TrimRight(cmdline, "\x00"); len(cmdline) > 0 {
+		args = strings.Split(string(cmdline), "\x00")
+	}
+
+	return &Process{Pid: pid, Pgrp: pgrp, Name: string(stat[nameStart+1 : nameEnd]), Args: args}, start, nil
+}
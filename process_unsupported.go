@@ -0,0 +1,13 @@
+//go:build !linux || !go1.16
+// +build !linux !go1.16
+
+package pty
+
+import (
+	"os"
+)
+
+// ForegroundProcess returns the process running in the foreground of the terminal t.
+func ForegroundProcess(*os.File) (*Process, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,144 @@
+package pty
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// ErrNoCommand is returned when waiting or signaling a Pty
+// which was not started with a command.
+var ErrNoCommand = errors.New("no command")
+
+// Pty is a pseudo-terminal. It owns the pty side (the master), the name
+// of the corresponding tty (the slave) and, when started with a command,
+// the child process.
+type Pty struct {
+	master *os.File
+	name   string
+	cmd    *exec.Cmd
+
+	mu  sync.Mutex
+	tty *os.File // Nil once closed.
+
+	waitOnce sync.Once
+	waitErr  error         // Error of the command's Wait, set once exited.
+	exited   chan struct{} // Closed once the command exited.
+}
+
+// OpenPty opens a new Pty. The tty is kept open until CloseTty or Close is called.
+//
+// On Linux, the pty is opened in non-blocking mode, see OpenOptions.
+func OpenPty() (*Pty, error) {
+	pty, tty, err := openWithOptions(OpenOptions{NonBlocking: ptyNonBlocking})
+	if err != nil {
+		return nil, err
+	}
+	return &Pty{master: pty, name: tty.Name(), tty: tty}, nil
+}
+
+// Master returns the pty side of p.
+func (p *Pty) Master() *os.File {
+	return p.master
+}
+
+// Tty returns the tty side of p, or nil if it has been closed.
+//
+// When p has been started with a command, the tty is closed as
+// soon as the command is started.
+func (p *Pty) Tty() *os.File {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tty
+}
+
+// Cmd returns the command p has been started with, if any.
+func (p *Pty) Cmd() *exec.Cmd {
+	return p.cmd
+}
+
+// Name returns the name of the tty, e.g. /dev/pts/0.
+func (p *Pty) Name() string {
+	return p.name
+}
+
+// Fd returns the file descriptor of the pty side.
+//
+// See (*os.File).Fd() for caveats: the pty is put in blocking mode.
+func (p *Pty) Fd() uintptr {
+	return p.master.Fd()
+}
+
+// Read reads from the pty side.
+func (p *Pty) Read(b []byte) (int, error) {
+	return p.master.Read(b)
+}
+
+// Write writes to the pty side.
+func (p *Pty) Write(b []byte) (int, error) {
+	return p.master.Write(b)
+}
+
+// Resize resizes the terminal to ws.
+func (p *Pty) Resize(ws *Winsize) error {
+	return Setsize(p.master, ws)
+}
+
+// Size returns the current terminal size.
+func (p *Pty) Size() (*Winsize, error) {
+	return GetsizeFull(p.master)
+}
+
+// Signal sends sig to the command.
+func (p *Pty) Signal(sig os.Signal) error {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return ErrNoCommand
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// SignalForeground sends sig to the foreground process group of the tty,
+// e.g. the job currently run by a shell. See SignalForeground().
+func (p *Pty) SignalForeground(sig syscall.Signal) error {
+	return SignalForeground(p.master, sig)
+}
+
+// Wait waits for the command to exit. See (*exec.Cmd).Wait().
+// Unlike the latter, it may be called several times, concurrently.
+//
+// The pty is not closed, so any remaining output can still be read.
+func (p *Pty) Wait() error {
+	if p.cmd == nil {
+		return ErrNoCommand
+	}
+	p.waitOnce.Do(func() {
+		p.waitErr = p.cmd.Wait()
+		close(p.exited)
+	})
+	return p.waitErr
+}
+
+// CloseTty closes the tty side if still open.
+func (p *Pty) CloseTty() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tty == nil {
+		return nil
+	}
+	err := p.tty.Close()
+	p.tty = nil
+	return err
+}
+
+// Close closes the pty side and the tty side if still open.
+// It does not wait for the command.
+func (p *Pty) Close() error {
+	err := p.CloseTty()
+	if e := p.master.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
@@ -41,6 +41,8 @@ func open() (pty, tty *os.File, err error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	// Keep the historical blocking behavior: Fd() puts the file in blocking mode.
+	_ = p.Fd()
 	return p, t, nil
 }
 
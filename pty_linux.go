@@ -10,8 +10,15 @@ import (
 	"unsafe"
 )
 
+// The Pty type defaults to a non-blocking pty.
+const ptyNonBlocking = true
+
 func open() (pty, tty *os.File, err error) {
-	p, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	return openWithOptions(OpenOptions{})
+}
+
+func openWithOptions(opts OpenOptions) (pty, tty *os.File, err error) {
+	p, err := openPtmx(opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -31,13 +38,56 @@ func open() (pty, tty *os.File, err error) {
 		return nil, nil, err
 	}
 
-	t, err := os.OpenFile(sname, os.O_RDWR|syscall.O_NOCTTY, 0) //nolint:gosec // Expected Open from a variable.
+	t, err := openPeer(p, sname)
+	if noPeer(err) && opts.PtmxPath == "" && opts.DevptsFD == nil {
+		// TIOCGPTPEER is not available, fall back to opening the tty by path.
+		// Not possible for another ptmx, as /dev/pts may be another devpts instance.
+		t, err = os.OpenFile(sname, os.O_RDWR|syscall.O_NOCTTY, 0) //nolint:gosec // Expected Open from a variable.
+	}
 	if err != nil {
 		return nil, nil, err
 	}
+
+	if !opts.NonBlocking {
+		// Keep the historical blocking behavior: Fd() puts the file in blocking mode.
+		_ = p.Fd()
+	}
 	return p, t, nil
 }
 
+// noPeer reports whether err, returned by openPeer, means that TIOCGPTPEER
+// is not available: the kernel fails with ENOTTY or EINVAL before Linux 4.13.
+func noPeer(err error) bool {
+	// openPeer returns the errno of the ioctl as is.
+	return err == syscall.ENOTTY || err == syscall.EINVAL || err == ErrUnsupported
+}
+
+// openPtmx opens the ptmx device designated by opts, /dev/ptmx by default.
+func openPtmx(opts OpenOptions) (*os.File, error) {
+	if opts.PtmxPath == "" && opts.DevptsFD == nil {
+		// The fd is put in non-blocking mode and registered with the runtime poller by os.OpenFile.
+		return os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	}
+
+	dirfd, path := _AT_FDCWD, opts.PtmxPath
+	if opts.DevptsFD != nil {
+		dirfd = *opts.DevptsFD
+		if path == "" {
+			path = "ptmx"
+		}
+	}
+	flags := os.O_RDWR | syscall.O_NOCTTY | syscall.O_CLOEXEC
+	if opts.NonBlocking {
+		// A non-blocking fd is registered with the runtime poller by os.NewFile.
+		flags |= syscall.O_NONBLOCK
+	}
+	fd, err := syscall.Openat(dirfd, path, flags, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "openat", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
+
 func ptsname(f *os.File) (string, error) {
 	var n _C_uint
 	err := ioctl(f, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))) //nolint:gosec // Expected unsafe pointer for Syscall call.
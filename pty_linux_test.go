@@ -0,0 +1,126 @@
+//go:build linux
+// +build linux
+
+package pty
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// inMountNamespace runs the current test again in a child process with a private
+// mount namespace and calls fn there. The test is skipped when namespaces are not available.
+func inMountNamespace(t *testing.T, fn func(t *testing.T)) {
+	t.Helper()
+
+	if os.Getenv("PTY_TEST_MOUNT_NAMESPACE") == t.Name() {
+		fn(t)
+		return
+	}
+
+	c := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v") //nolint:gosec // Expected Command from a variable.
+	c.Env = append(os.Environ(), "PTY_TEST_MOUNT_NAMESPACE="+t.Name())
+	c.SysProcAttr = &syscall.SysProcAttr{Unshareflags: syscall.CLONE_NEWNS}
+	out, err := c.CombinedOutput()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			t.Skipf("Unable to create a mount namespace: %s.", err)
+		}
+		t.Fatalf("Unexpected error in the mount namespace: %s\n%s", err, out)
+	}
+	if strings.Contains(string(out), "--- SKIP") {
+		t.Skipf("Skipped in the mount namespace:\n%s", out)
+	}
+}
+
+// mountDevpts mounts a fresh devpts instance and returns its directory.
+func mountDevpts(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := syscall.Mount("devpts", dir, "devpts", 0, "newinstance,ptmxmode=0666"); err != nil {
+		t.Skipf("Unable to mount devpts: %s.", err)
+	}
+	t.Cleanup(func() { _ = syscall.Unmount(dir, 0) }) // Best effort.
+	return dir
+}
+
+// assertDevpts asserts tty belongs to the devpts instance mounted on dir and is the peer of pty.
+func assertDevpts(t *testing.T, dir string, pty, tty *os.File) {
+	t.Helper()
+
+	var devpts, ttyStat syscall.Stat_t
+	noError(t, syscall.Stat(dir, &devpts), "Unexpected error from Stat")
+	noError(t, syscall.Fstat(int(tty.Fd()), &ttyStat), "Unexpected error from Fstat")
+	if ttyStat.Dev != devpts.Dev {
+		t.Fatalf("Unexpected tty outside of the devpts instance: %s.", tty.Name())
+	}
+
+	_, err := tty.Write([]byte("x"))
+	noError(t, err, "Unexpected error from tty Write")
+	assertBytes(t, []byte("x"), readN(t, pty, 1, "Unexpected error reading the pty"), "Unexpected output")
+}
+
+// TestOpenPrivateDevpts makes /dev/ptmx a link to the ptmx of a fresh devpts instance,
+// as done in some containers, so /dev/pts/N doesn't designate the tty of the opened pty.
+func TestOpenPrivateDevpts(t *testing.T) {
+	t.Parallel()
+
+	inMountNamespace(t, func(t *testing.T) {
+		dir := mountDevpts(t)
+		if err := syscall.Mount("tmpfs", "/dev", "tmpfs", 0, ""); err != nil {
+			t.Skipf("Unable to mount /dev: %s.", err)
+		}
+		noError(t, os.Symlink(dir+"/ptmx", "/dev/ptmx"), "Unexpected error from Symlink")
+
+		pty, tty := openClose(t)
+		assertDevpts(t, dir, pty, tty)
+	})
+}
+
+func TestOpenWithOptionsDevptsFD(t *testing.T) {
+	t.Parallel()
+
+	inMountNamespace(t, func(t *testing.T) {
+		dir := mountDevpts(t)
+		d, err := os.Open(dir)
+		noError(t, err, "Unexpected error from Open")
+		defer func() { _ = d.Close() }() // Best effort.
+
+		fd := int(d.Fd())
+		pty, tty, err := OpenWithOptions(OpenOptions{DevptsFD: &fd})
+		noError(t, err, "Unexpected error from OpenWithOptions")
+		defer func() { _, _ = pty.Close(), tty.Close() }() // Best effort.
+
+		assertDevpts(t, dir, pty, tty)
+	})
+}
+
+func TestOpenWithOptionsPtmxPath(t *testing.T) {
+	t.Parallel()
+
+	inMountNamespace(t, func(t *testing.T) {
+		dir := mountDevpts(t)
+
+		pty, tty, err := OpenWithOptions(OpenOptions{PtmxPath: dir + "/ptmx", NonBlocking: true})
+		noError(t, err, "Unexpected error from OpenWithOptions")
+		defer func() { _, _ = pty.Close(), tty.Close() }() // Best effort.
+
+		assertDevpts(t, dir, pty, tty)
+	})
+}
+
+func TestNoPeer(t *testing.T) {
+	t.Parallel()
+
+	for _, err := range []error{syscall.ENOTTY, syscall.EINVAL, ErrUnsupported} {
+		assert(t, true, noPeer(err), "Unexpected noPeer for "+err.Error())
+	}
+	for _, err := range []error{nil, syscall.EMFILE, syscall.EBADF} {
+		assert(t, false, noPeer(err), "Unexpected noPeer for "+fmt.Sprint(err))
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package pty
+
+import (
+	"os"
+)
+
+// The Pty type defaults to a blocking pty.
+const ptyNonBlocking = false
+
+func openWithOptions(opts OpenOptions) (pty, tty *os.File, err error) {
+	if opts.NonBlocking || opts.PtmxPath != "" || opts.DevptsFD != nil {
+		return nil, nil, ErrUnsupported
+	}
+	return open()
+}
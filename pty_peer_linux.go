@@ -0,0 +1,36 @@
+//go:build linux && go1.12
+// +build linux,go1.12
+
+package pty
+
+import (
+	"os"
+	"syscall"
+)
+
+// openPeer opens the tty of the pty p with TIOCGPTPEER (Linux 4.13+).
+//
+// Unlike opening the tty by path, it always yields the peer of p, even when
+// /dev/pts is not the devpts instance of /dev/ptmx or the pts got reused.
+func openPeer(p *os.File, name string) (*os.File, error) {
+	sc, err := p.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fd uintptr
+		e  syscall.Errno
+	)
+	// The tty is kept in blocking mode as it is meant to be inherited by the command.
+	flags := uintptr(os.O_RDWR | syscall.O_NOCTTY | syscall.O_CLOEXEC)
+	if err := sc.Control(func(ptmx uintptr) {
+		fd, _, e = syscall.Syscall(syscall.SYS_IOCTL, ptmx, ioctl_TIOCGPTPEER, flags)
+	}); err != nil {
+		return nil, err
+	}
+	if e != 0 {
+		return nil, e
+	}
+	return os.NewFile(fd, name), nil
+}
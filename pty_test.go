@@ -0,0 +1,116 @@
+//go:build !windows
+// +build !windows
+
+package pty
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// openPty opens a Pty and stages the closing as part of the cleanup.
+func openPty(t *testing.T) *Pty {
+	t.Helper()
+
+	p, err := OpenPty()
+	noError(t, err, "Unexpected error from OpenPty")
+	t.Cleanup(func() {
+		if err := p.Close(); err != nil {
+			t.Errorf("Unexpected error from Pty Close: %s.", err)
+		}
+	})
+	return p
+}
+
+// startPtyCleanup starts c in a Pty and stages the closing and killing of c as part of the cleanup.
+func startPtyCleanup(t *testing.T, c *exec.Cmd) *Pty {
+	t.Helper()
+
+	p, err := StartPty(c, nil)
+	noError(t, err, "Unexpected error from StartPty")
+	t.Cleanup(func() {
+		_ = p.Close()           // Best effort.
+		_ = c.Process.Kill()    // Best effort.
+		_, _ = c.Process.Wait() // Best effort.
+	})
+	return p
+}
+
+func TestOpenPty(t *testing.T) {
+	t.Parallel()
+
+	p := openPty(t)
+
+	tty := p.Tty()
+	if tty == nil {
+		t.Fatal("Expected tty to be open.")
+	}
+	assert(t, tty.Name(), p.Name(), "Unexpected Pty Name")
+
+	text := []byte("ping")
+	_, err := tty.Write(text)
+	noError(t, err, "Unexpected error from tty Write")
+
+	buffer := readN(t, p, len(text), "Unexpected error from Pty Read")
+	assertBytes(t, text, buffer, "Unexpected result returned from Pty Read")
+
+	noError(t, p.CloseTty(), "Unexpected error from CloseTty")
+	if p.Tty() != nil {
+		t.Error("Expected tty to be closed.")
+	}
+	noError(t, p.CloseTty(), "Unexpected error from second CloseTty")
+
+	if err := p.Wait(); !errors.Is(err, ErrNoCommand) {
+		t.Errorf("Unexpected error from Wait without command: %v.", err)
+	}
+	if err := p.Signal(os.Interrupt); !errors.Is(err, ErrNoCommand) {
+		t.Errorf("Unexpected error from Signal without command: %v.", err)
+	}
+}
+
+func TestPtyResize(t *testing.T) {
+	t.Parallel()
+
+	p := openPty(t)
+
+	ws := &Winsize{Rows: 24, Cols: 80, X: 1, Y: 2}
+	noError(t, p.Resize(ws), "Unexpected error from Resize")
+
+	size, err := p.Size()
+	noError(t, err, "Unexpected error from Size")
+	assert(t, *ws, *size, "Unexpected Size after Resize")
+
+	tsize, err := GetsizeFull(p.Tty())
+	noError(t, err, "Unexpected error from tty GetsizeFull")
+	assert(t, *ws, *tsize, "Unexpected tty size after Resize")
+}
+
+func TestStartPty(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("cat")
+	p := startPtyCleanup(t, c)
+
+	if p.Tty() != nil {
+		t.Error("Expected tty to be closed once the command started.")
+	}
+	if p.Name() == "" {
+		t.Error("Pty name was empty.")
+	}
+	assert(t, c, p.Cmd(), "Unexpected Pty Cmd")
+
+	_, err := p.Write([]byte("ping\n"))
+	noError(t, err, "Unexpected error from Pty Write")
+
+	// Echo followed by cat's output.
+	expect := []byte("ping\r\nping\r\n")
+	buffer := readN(t, p, len(expect), "Unexpected error from Pty Read")
+	assertBytes(t, expect, buffer, "Unexpected result returned from Pty Read")
+
+	noError(t, p.Signal(os.Kill), "Unexpected error from Signal")
+	if err := p.Wait(); err == nil {
+		t.Error("Expected error from Wait on killed command.")
+	}
+}
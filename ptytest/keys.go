@@ -0,0 +1,22 @@
+package ptytest
+
+// Key is the sequence sent by a key of the keyboard.
+//
+// Cursor keys are given in normal mode, not in application mode (DECCKM).
+type Key string
+
+// Keys.
+const (
+	KeyUp        Key = "\x1b[A"
+	KeyDown      Key = "\x1b[B"
+	KeyRight     Key = "\x1b[C"
+	KeyLeft      Key = "\x1b[D"
+	KeyHome      Key = "\x1b[H"
+	KeyEnd       Key = "\x1b[F"
+	KeyEnter     Key = "\r"
+	KeyTab       Key = "\t"
+	KeyBackspace Key = "\x7f"
+	KeyEscape    Key = "\x1b"
+	KeyCtrlC     Key = "\x03"
+	KeyCtrlD     Key = "\x04"
+)
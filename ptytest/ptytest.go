@@ -0,0 +1,224 @@
+// Package ptytest helps testing interactive command line programs by running
+// them in a pty and asserting on their output and rendered screen.
+package ptytest
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/creack/pty/vt"
+)
+
+// DefaultTimeout is the default time waited for an expectation before
+// failing the test.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultSize is the pty size used when New is given a nil size.
+var DefaultSize = pty.Winsize{Rows: 24, Cols: 80}
+
+// Term is a command running in a pty, bound to a test.
+//
+// Its methods must be called from the test goroutine, as they fail the test
+// with t.Fatal.
+type Term struct {
+	t   testing.TB
+	pty *pty.Pty
+	cmd *exec.Cmd
+
+	// Timeout is the time waited by ExpectOutput and WaitForScreen before
+	// failing the test. It acts as a watchdog, so a test never hangs.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	out     []byte        // The whole output.
+	pos     int           // Output position after the last ExpectOutput match.
+	screen  *vt.Screen    // Rendered output.
+	err     error         // Read error, the output ended when set.
+	changed chan struct{} // Closed and replaced when the output changes.
+	done    chan struct{} // Closed when the read loop returns.
+}
+
+// New starts cmd in a pty of the given size, DefaultSize if nil, and stages
+// the termination of the command as part of the test cleanup.
+func New(t testing.TB, cmd *exec.Cmd, size *pty.Winsize) *Term {
+	t.Helper()
+
+	if size == nil {
+		ws := DefaultSize
+		size = &ws
+	}
+	p, err := pty.StartPty(cmd, size)
+	if err != nil {
+		t.Fatalf("Unexpected error from StartPty: %s.", err)
+	}
+	tm := &Term{
+		t:       t,
+		pty:     p,
+		cmd:     cmd,
+		Timeout: DefaultTimeout,
+		screen:  vt.NewScreen(int(size.Rows), int(size.Cols)),
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go tm.readLoop()
+
+	t.Cleanup(func() {
+		_ = p.Close()             // Best effort.
+		_ = cmd.Process.Kill()    // Best effort.
+		_, _ = cmd.Process.Wait() // Best effort.
+		select {
+		case <-tm.done:
+		case <-time.After(DefaultTimeout):
+			t.Errorf("Read loop did not return.")
+		}
+	})
+	return tm
+}
+
+// Pty returns the pty running the command.
+func (tm *Term) Pty() *pty.Pty {
+	return tm.pty
+}
+
+func (tm *Term) readLoop() {
+	defer close(tm.done)
+
+	b := make([]byte, 32*1024)
+	for {
+		n, err := tm.pty.Read(b)
+
+		tm.mu.Lock()
+		tm.out = append(tm.out, b[:n]...)
+		_, _ = tm.screen.Write(b[:n]) // Never fails.
+		if err != nil {
+			tm.err = err
+		}
+		close(tm.changed)
+		tm.changed = make(chan struct{})
+		tm.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait calls cond each time the output changes, until it returns true.
+// Otherwise, the test fails after Timeout or once the output ended.
+func (tm *Term) wait(what string, cond func() bool) {
+	tm.t.Helper()
+
+	timer := time.NewTimer(tm.Timeout)
+	defer timer.Stop()
+
+	for {
+		// Any output written after this point is seen by the next call of cond.
+		tm.mu.Lock()
+		ended, changed := tm.err != nil, tm.changed
+		tm.mu.Unlock()
+		if cond() {
+			return
+		}
+
+		if ended {
+			tm.fatalf("Output ended while waiting for %s.", what)
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			tm.fatalf("Timeout after %s waiting for %s.", tm.Timeout, what)
+		}
+	}
+}
+
+// ExpectOutput waits for s to be written by the command after the previous
+// match, and fails the test otherwise.
+func (tm *Term) ExpectOutput(s string) {
+	tm.t.Helper()
+
+	tm.wait(visible([]byte(s)), func() bool {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+
+		i := bytes.Index(tm.out[tm.pos:], []byte(s))
+		if i < 0 {
+			return false
+		}
+		tm.pos += i + len(s)
+		return true
+	})
+}
+
+// WaitForScreen waits for cond to return true for the rendered screen, and
+// fails the test otherwise. cond may call the methods of tm.
+func (tm *Term) WaitForScreen(cond func(Screen) bool) {
+	tm.t.Helper()
+
+	tm.wait("the screen condition", func() bool { return cond(tm.Screen()) })
+}
+
+// Screen returns the currently rendered screen.
+func (tm *Term) Screen() Screen {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return snapshot(tm.screen)
+}
+
+// Type writes s to the command, as if typed on a keyboard.
+func (tm *Term) Type(s string) {
+	tm.t.Helper()
+
+	if _, err := tm.pty.Write([]byte(s)); err != nil {
+		tm.fatalf("Unexpected error from Write: %s.", err)
+	}
+}
+
+// PressKey writes the sequence of key to the command.
+func (tm *Term) PressKey(key Key) {
+	tm.t.Helper()
+
+	tm.Type(string(key))
+}
+
+// fatalf fails the test, dumping the rendered screen and the raw output.
+func (tm *Term) fatalf(format string, args ...interface{}) {
+	tm.t.Helper()
+
+	tm.mu.Lock()
+	screen, out := snapshot(tm.screen), visible(tm.out)
+	tm.mu.Unlock()
+
+	border := "+" + strings.Repeat("-", screen.Cols) + "+"
+	var b strings.Builder
+	b.WriteString(border + "\n")
+	for _, line := range screen.Lines {
+		b.WriteString("|" + line + strings.Repeat(" ", screen.Cols-len([]rune(line))) + "|\n")
+	}
+	b.WriteString(border)
+
+	tm.t.Fatalf(format+"\nScreen (cursor at %d,%d):\n%s\nOutput:\n%s", append(args, screen.Row, screen.Col, b.String(), out)...)
+}
+
+// visible returns b with control characters in caret notation, e.g. ^[ for
+// escape, and a line break after each ^J.
+func visible(b []byte) string {
+	var s strings.Builder
+	for _, r := range string(b) {
+		switch {
+		case r == '\n':
+			s.WriteString("^J\n")
+		case r < 0x20:
+			s.WriteString("^" + string(rune(r+0x40)))
+		case r == 0x7f:
+			s.WriteString("^?")
+		default:
+			s.WriteRune(r)
+		}
+	}
+	return s.String()
+}
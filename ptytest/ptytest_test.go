@@ -0,0 +1,87 @@
+//go:build !windows
+// +build !windows
+
+package ptytest
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/creack/pty/vt"
+)
+
+func TestTerm(t *testing.T) {
+	t.Parallel()
+
+	tm := New(t, exec.Command("sh", "-c", `printf "name? "; read name; echo "hello $name"`), nil)
+
+	tm.ExpectOutput("name? ")
+	tm.Type("bob")
+	tm.PressKey(KeyEnter)
+	tm.ExpectOutput("hello bob")
+	tm.WaitForScreen(func(s Screen) bool {
+		return s.Line(0) == "name? bob" && s.Line(1) == "hello bob"
+	})
+	// The condition may use the Term.
+	tm.WaitForScreen(func(Screen) bool { return tm.Screen().Row == 2 })
+}
+
+func TestTermPressKey(t *testing.T) {
+	t.Parallel()
+
+	tm := New(t, exec.Command("cat"), &pty.Winsize{Rows: 5, Cols: 20})
+
+	// The tty echoes control characters in caret notation.
+	tm.PressKey(KeyUp)
+	tm.WaitForScreen(func(s Screen) bool { return s.Line(0) == "^[[A" })
+
+	if s := tm.Screen(); s.Rows != 5 || s.Cols != 20 || s.Col != 4 {
+		t.Errorf("Unexpected screen: %+v.", s)
+	}
+}
+
+// fatalTB records the message of Fatalf instead of failing the test.
+type fatalTB struct {
+	testing.TB
+	msg chan string
+}
+
+func (f *fatalTB) Fatalf(format string, args ...interface{}) {
+	f.msg <- fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestTermFailureDump(t *testing.T) {
+	t.Parallel()
+
+	tb := &fatalTB{TB: t, msg: make(chan string, 1)}
+	tm := New(tb, exec.Command("sh", "-c", `printf "\033[31mred\033[0m\n"; cat`), &pty.Winsize{Rows: 2, Cols: 10})
+	tm.Timeout = 50 * time.Millisecond
+
+	go tm.ExpectOutput("never")
+
+	msg := <-tb.msg
+	for _, s := range []string{"Timeout", "|red       |", "^[[31mred^[[0m^M^J\n"} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("Expected %q in the failure message:\n%s", s, msg)
+		}
+	}
+}
+
+func TestScreenSnapshot(t *testing.T) {
+	t.Parallel()
+
+	s := vt.NewScreen(3, 5)
+	_, _ = s.Write([]byte("abcdefg\r\nh\xc3\xa9\x1b[1;3Hx\x1b[2;1H\x1b[K"))
+
+	got := snapshot(s)
+	want := "abxde\n\nhé"
+	if got.String() != want || got.Rows != 3 || got.Cols != 5 || got.Row != 1 || got.Col != 0 {
+		t.Errorf("Unexpected screen %q (%dx%d) at %d,%d, expected %q (3x5) at 1,0.", got.String(), got.Rows, got.Cols, got.Row, got.Col, want)
+	}
+}
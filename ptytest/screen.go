@@ -0,0 +1,53 @@
+package ptytest
+
+import (
+	"strings"
+
+	"github.com/creack/pty/vt"
+)
+
+// Screen is a snapshot of the terminal as rendered from the command output.
+type Screen struct {
+	// Lines holds the visible lines, without trailing spaces.
+	Lines []string
+
+	// Rows and Cols are the size of the screen.
+	Rows, Cols int
+
+	// Row and Col are the 0-based cursor position.
+	Row, Col int
+}
+
+// String returns the visible lines separated by line breaks.
+func (s Screen) String() string {
+	return strings.Join(s.Lines, "\n")
+}
+
+// Contains tells whether text is visible on one of the lines.
+func (s Screen) Contains(text string) bool {
+	for _, line := range s.Lines {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Line returns the visible line i, or an empty string if out of the screen.
+func (s Screen) Line(i int) string {
+	if i < 0 || i >= len(s.Lines) {
+		return ""
+	}
+	return s.Lines[i]
+}
+
+// snapshot returns the current state of s.
+func snapshot(s *vt.Screen) Screen {
+	rows, cols := s.Size()
+	row, col := s.Cursor()
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = s.Line(i)
+	}
+	return Screen{Lines: lines, Rows: rows, Cols: cols, Row: row, Col: col}
+}
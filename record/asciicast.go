@@ -0,0 +1,174 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of the asciicast events.
+const (
+	EventOutput = "o" // Output of the program.
+	EventInput  = "i" // Input typed by the user.
+	EventResize = "r" // Resize of the terminal, as "COLSxROWS".
+	EventMarker = "m" // Marker, with an optional label.
+)
+
+// Header is the first line of an asciicast v2 recording.
+// See https://docs.asciinema.org/manual/asciicast/v2/.
+type Header struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp,omitempty"` // Unix time of the start of the recording.
+	Duration      float64           `json:"duration,omitempty"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Command       string            `json:"command,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+// Event is an event of an asciicast v2 recording.
+type Event struct {
+	Time time.Duration // Since the start of the recording.
+	Type string
+	Data string
+}
+
+// AsciicastEncoder writes an asciicast v2 recording.
+type AsciicastEncoder struct {
+	w       io.Writer
+	pending map[string][]byte // Incomplete UTF-8 sequence ending the data, per event type.
+}
+
+// NewAsciicastEncoder writes the header h to w and returns an encoder of
+// the events following it.
+func NewAsciicastEncoder(w io.Writer, h *Header) (*AsciicastEncoder, error) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return nil, err
+	}
+	return &AsciicastEncoder{w: w, pending: map[string][]byte{}}, nil
+}
+
+// Encode writes ev as a line of JSON: [time, type, data]. The incomplete
+// UTF-8 sequence ending the data is held back, to be written with the next
+// event of the same type. Invalid UTF-8 is replaced by U+FFFD.
+func (e *AsciicastEncoder) Encode(ev Event) error {
+	data := append(e.pending[ev.Type], ev.Data...)
+	n := completeUTF8(data)
+	e.pending[ev.Type] = append([]byte(nil), data[n:]...)
+	if n == 0 && len(ev.Data) > 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	buf.WriteString(strconv.FormatFloat(ev.Time.Seconds(), 'f', 6, 64))
+	buf.WriteString(", ")
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(ev.Type); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // Trailing line feed of Encode.
+	buf.WriteString(", ")
+	if err := enc.Encode(string(data[:n])); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteString("]\n")
+
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// completeUTF8 returns the length of b without the incomplete UTF-8 sequence
+// ending it, if any.
+func completeUTF8(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i > len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
+	}
+	return len(b)
+}
+
+// AsciicastDecoder reads an asciicast v2 recording.
+type AsciicastDecoder struct {
+	r      *bufio.Reader
+	header Header
+	line   int
+}
+
+// NewAsciicastDecoder reads the header of the asciicast v2 recording r and
+// returns a decoder of its events.
+func NewAsciicastDecoder(r io.Reader) (*AsciicastDecoder, error) {
+	d := &AsciicastDecoder{r: bufio.NewReader(r)}
+	b, err := d.readLine()
+	if err == io.EOF {
+		return nil, fmt.Errorf("asciicast: missing header: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &d.header); err != nil {
+		return nil, fmt.Errorf("asciicast: invalid header: %w", err)
+	}
+	if d.header.Version != 2 {
+		return nil, fmt.Errorf("asciicast: unsupported version %d", d.header.Version)
+	}
+	return d, nil
+}
+
+// Header returns the header of the recording.
+func (d *AsciicastDecoder) Header() *Header {
+	return &d.header
+}
+
+// Decode returns the next event, or io.EOF at the end of the recording.
+func (d *AsciicastDecoder) Decode() (Event, error) {
+	b, err := d.readLine()
+	if err != nil {
+		return Event{}, err
+	}
+
+	var (
+		sec  float64
+		ev   Event
+		line = []interface{}{&sec, &ev.Type, &ev.Data}
+	)
+	if err := json.Unmarshal(b, &line); err != nil || len(line) != 3 {
+		return Event{}, fmt.Errorf("asciicast: invalid event on line %d: %q", d.line, b)
+	}
+	ev.Time = time.Duration(sec * float64(time.Second))
+	return ev, nil
+}
+
+// readLine returns the next line which is not blank.
+func (d *AsciicastDecoder) readLine() ([]byte, error) {
+	for {
+		b, err := d.r.ReadBytes('\n')
+		if len(b) > 0 {
+			d.line++
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			return b, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
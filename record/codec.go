@@ -0,0 +1,48 @@
+package record
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Encoder writes the events of a recording.
+type Encoder interface {
+	// Encode writes the event ev. The events are timed from the start of
+	// the recording, in order.
+	Encode(ev Event) error
+}
+
+// Decoder reads the events of a recording.
+type Decoder interface {
+	// Decode returns the next event, or io.EOF at the end of the recording.
+	Decode() (Event, error)
+}
+
+// NewDecoder returns a decoder of the recording r: an AsciicastDecoder if
+// it starts with '{', a TtyrecDecoder otherwise.
+func NewDecoder(r io.Reader) (Decoder, error) {
+	br := bufio.NewReader(r)
+	if b, _ := br.Peek(1); len(b) > 0 && b[0] == '{' {
+		return NewAsciicastDecoder(br)
+	}
+	return NewTtyrecDecoder(br), nil
+}
+
+// Convert writes the events read from d to e, e.g. to convert a ttyrec
+// recording to asciicast. The events not supported by the format of e are
+// dropped by e.
+func Convert(e Encoder, d Decoder) error {
+	for {
+		ev, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := e.Encode(ev); err != nil {
+			return err
+		}
+	}
+}
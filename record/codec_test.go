@@ -0,0 +1,242 @@
+//go:build !windows
+// +build !windows
+
+package record
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+var testEvents = []Event{
+	{Time: 0, Type: EventOutput, Data: "$ "},
+	{Time: 500 * time.Millisecond, Type: EventInput, Data: "l"},
+	{Time: 600 * time.Millisecond, Type: EventOutput, Data: "l"},
+	{Time: 700 * time.Millisecond, Type: EventMarker, Data: "mark"},
+	{Time: 1200 * time.Millisecond, Type: EventResize, Data: "100x30"},
+	{Time: 2000001 * time.Microsecond, Type: EventOutput, Data: "\x1b[1mhé\x1b[m\r\n"},
+}
+
+// decodeAll returns the events of d.
+func decodeAll(t *testing.T, d Decoder) []Event {
+	t.Helper()
+
+	var events []Event
+	for {
+		ev, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error from Decode: %s.", err)
+		}
+		events = append(events, ev)
+	}
+}
+
+// filterEvents returns the events of the given types.
+func filterEvents(events []Event, types ...string) []Event {
+	var filtered []Event
+	for _, ev := range events {
+		for _, typ := range types {
+			if ev.Type == typ {
+				filtered = append(filtered, ev)
+			}
+		}
+	}
+	return filtered
+}
+
+func TestAsciicastRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var cast bytes.Buffer
+	h := &Header{Version: 2, Width: 80, Height: 24, Timestamp: 1700000000, Env: map[string]string{"TERM": "xterm"}}
+	e, err := NewAsciicastEncoder(&cast, h)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewAsciicastEncoder: %s.", err)
+	}
+	for _, ev := range testEvents {
+		if err := e.Encode(ev); err != nil {
+			t.Fatalf("Unexpected error from Encode: %s.", err)
+		}
+	}
+
+	d, err := NewAsciicastDecoder(&cast)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewAsciicastDecoder: %s.", err)
+	}
+	if !reflect.DeepEqual(d.Header(), h) {
+		t.Errorf("Unexpected header: %+v != %+v.", d.Header(), h)
+	}
+	if got := decodeAll(t, d); !reflect.DeepEqual(got, testEvents) {
+		t.Errorf("Unexpected events: %+v != %+v.", got, testEvents)
+	}
+}
+
+func TestTtyrecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var rec bytes.Buffer
+	e := NewTtyrecEncoder(&rec, time.Unix(1700000000, 999999000))
+	for _, ev := range testEvents {
+		if err := e.Encode(ev); err != nil {
+			t.Fatalf("Unexpected error from Encode: %s.", err)
+		}
+	}
+
+	// Only the output is recorded.
+	want := filterEvents(testEvents, EventOutput)
+	if got := decodeAll(t, NewTtyrecDecoder(&rec)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected events: %+v != %+v.", got, want)
+	}
+}
+
+func TestTypescriptRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var typescript, timing bytes.Buffer
+	h := &Header{Version: 2, Width: 80, Height: 24, Timestamp: 1700000000, Command: "sh", Env: map[string]string{"TERM": "xterm", "SHELL": "/bin/sh"}}
+	e, err := NewTypescriptEncoder(&typescript, &timing, h)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTypescriptEncoder: %s.", err)
+	}
+	for _, ev := range testEvents {
+		if err := e.Encode(ev); err != nil {
+			t.Fatalf("Unexpected error from Encode: %s.", err)
+		}
+	}
+
+	start := time.Unix(1700000000, 0).Format(typescriptTime)
+	if want := "Script started on " + start + ` [COMMAND="sh" TERM="xterm" COLUMNS="80" LINES="24"]` + "\n$ ll\x1b[1mhé\x1b[m\r\n"; typescript.String() != want {
+		t.Errorf("Unexpected typescript: %q != %q.", typescript.String(), want)
+	}
+	if want := "H 0.000000 START_TIME " + start + "\n" +
+		"H 0.000000 COMMAND sh\n" +
+		"H 0.000000 TERM xterm\n" +
+		"H 0.000000 SHELL /bin/sh\n" +
+		"H 0.000000 COLUMNS 80\n" +
+		"H 0.000000 LINES 24\n" +
+		"O 0.000000 2\n" +
+		"I 0.500000 1\n" +
+		"O 0.100000 1\n" +
+		"S 0.600000 SIGWINCH ROWS=30 COLS=100\n" +
+		"O 0.800001 12\n"; timing.String() != want {
+		t.Errorf("Unexpected timing: %q != %q.", timing.String(), want)
+	}
+
+	d, err := NewTypescriptDecoder(&typescript, &timing)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTypescriptDecoder: %s.", err)
+	}
+	if !reflect.DeepEqual(d.Header(), h) {
+		t.Errorf("Unexpected header: %+v != %+v.", d.Header(), h)
+	}
+	want := filterEvents(testEvents, EventOutput, EventInput, EventResize)
+	if got := decodeAll(t, d); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected events: %+v != %+v.", got, want)
+	}
+}
+
+func TestTypescriptDecoder(t *testing.T) {
+	t.Parallel()
+
+	// Classic timing format, without typescript header.
+	d, err := NewTypescriptDecoder(strings.NewReader("hello world"), strings.NewReader("0.5 6\n1.25 5\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTypescriptDecoder: %s.", err)
+	}
+	want := []Event{
+		{Time: 500 * time.Millisecond, Type: EventOutput, Data: "hello "},
+		{Time: 1750 * time.Millisecond, Type: EventOutput, Data: "world"},
+	}
+	if got := decodeAll(t, d); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected events: %+v != %+v.", got, want)
+	}
+
+	// Signals other than SIGWINCH are dropped, their delay is kept.
+	d, err = NewTypescriptDecoder(strings.NewReader("ab"), strings.NewReader("S 1.0 SIGTERM\nO 1.0 1\nO 0 2\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTypescriptDecoder: %s.", err)
+	}
+	if ev, err := d.Decode(); err != nil || ev != (Event{Time: 2 * time.Second, Type: EventOutput, Data: "a"}) {
+		t.Errorf("Unexpected event: %+v, %v.", ev, err)
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Unexpected error from Decode with truncated data: %v.", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	var rec, cast bytes.Buffer
+	e := NewTtyrecEncoder(&rec, time.Unix(1700000000, 0))
+	for _, ev := range testEvents {
+		if err := e.Encode(ev); err != nil {
+			t.Fatalf("Unexpected error from Encode: %s.", err)
+		}
+	}
+
+	ae, err := NewAsciicastEncoder(&cast, &Header{Version: 2, Width: 80, Height: 24})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewAsciicastEncoder: %s.", err)
+	}
+	if err := Convert(ae, NewTtyrecDecoder(&rec)); err != nil {
+		t.Fatalf("Unexpected error from Convert: %s.", err)
+	}
+
+	d, err := NewDecoder(&cast)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewDecoder: %s.", err)
+	}
+	if got, want := decodeAll(t, d), filterEvents(testEvents, EventOutput); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected events: %+v != %+v.", got, want)
+	}
+}
+
+func TestEncoderRecorder(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("echo", "hello")
+	p, err := pty.Start(c)
+	if err != nil {
+		t.Fatalf("Unexpected error from Start: %s.", err)
+	}
+	defer func() { _ = p.Close() }() // Best effort.
+
+	var typescript, timing bytes.Buffer
+	e, err := NewTypescriptEncoder(&typescript, &timing, &Header{Version: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTypescriptEncoder: %s.", err)
+	}
+	r := NewEncoderRecorder(p, e)
+	// On Linux, reading the pty fails with EIO once echo exited.
+	out, _ := io.ReadAll(r)
+	if err := c.Wait(); err != nil {
+		t.Fatalf("Unexpected error from Wait: %s.", err)
+	}
+	if string(out) != "hello\r\n" {
+		t.Errorf("Unexpected output: %q.", out)
+	}
+
+	d, err := NewTypescriptDecoder(&typescript, &timing)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTypescriptDecoder: %s.", err)
+	}
+	var recorded string
+	for _, ev := range decodeAll(t, d) {
+		recorded += ev.Data
+	}
+	if recorded != string(out) {
+		t.Errorf("Unexpected recorded output: %q != %q.", recorded, out)
+	}
+}
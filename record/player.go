@@ -0,0 +1,297 @@
+package record
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// ErrPlaying is returned by Play when the Player is already playing.
+var ErrPlaying = errors.New("record: already playing")
+
+// PlayerOption configures a Player.
+type PlayerOption func(*Player)
+
+// WithSpeed sets the playback speed, e.g. 2 plays twice as fast.
+// The default is 1.
+func WithSpeed(speed float64) PlayerOption {
+	return func(p *Player) { p.speed = speed }
+}
+
+// WithIdleTimeLimit caps the pauses between events to d, zero or less
+// disables the limit. The default is the idle_time_limit of the asciicast
+// header, if any.
+func WithIdleTimeLimit(d time.Duration) PlayerOption {
+	return func(p *Player) { p.idle = &d }
+}
+
+// WithSetsize resizes the terminal t, usually the pty master written to, to
+// the size of the recording, and on its resize events. See pty.Setsize.
+func WithSetsize(t *os.File) PlayerOption {
+	return func(p *Player) { p.tty = t }
+}
+
+// Player replays a recording to a writer, e.g. a pty master, with the
+// timing of the recording.
+//
+// Pause, Resume, Seek and Position may be called while Play runs. Only
+// Seek waits for the output being written.
+type Player struct {
+	w      io.Writer
+	tty    *os.File
+	speed  float64
+	idle   *time.Duration
+	size   *pty.Winsize // Initial size, nil if unknown.
+	events []Event      // Timed with the idle time limit applied.
+
+	wmu sync.Mutex // Serializes the output, taken before mu.
+
+	mu      sync.Mutex
+	next    int           // Index of the next event to play.
+	pos     time.Duration // Position in the recording at anchor.
+	anchor  time.Time     // Wall time of pos, while the clock runs.
+	playing bool          // Play is running.
+	paused  bool
+	changed chan struct{} // Closed and replaced on Pause, Resume and Seek.
+}
+
+// NewPlayer reads the events of the recording d, and returns a Player
+// writing its output to w. The size of the recording is applied to the
+// terminal set by WithSetsize, if known.
+func NewPlayer(d Decoder, w io.Writer, opts ...PlayerOption) (*Player, error) {
+	p := &Player{w: w, speed: 1, changed: make(chan struct{})}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.speed <= 0 {
+		return nil, fmt.Errorf("record: invalid speed %v", p.speed)
+	}
+
+	idle := time.Duration(0)
+	if h, ok := d.(interface{ Header() *Header }); ok {
+		h := h.Header()
+		idle = time.Duration(h.IdleTimeLimit * float64(time.Second))
+		if h.Width > 0 && h.Height > 0 {
+			p.size = &pty.Winsize{Rows: uint16(h.Height), Cols: uint16(h.Width)}
+		}
+	}
+	if p.idle != nil {
+		idle = *p.idle
+	}
+
+	var last, skipped time.Duration
+	for {
+		ev, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ev.Time < last {
+			ev.Time = last
+		}
+		if gap := ev.Time - last; idle > 0 && gap > idle {
+			skipped += gap - idle
+		}
+		last = ev.Time
+		ev.Time -= skipped
+		p.events = append(p.events, ev)
+	}
+
+	if err := p.resize(p.size); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Duration returns the duration of the recording, idle time limit applied.
+func (p *Player) Duration() time.Duration {
+	if len(p.events) == 0 {
+		return 0
+	}
+	return p.events[len(p.events)-1].Time
+}
+
+// Position returns the position of the playback in the recording.
+func (p *Player) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position()
+}
+
+func (p *Player) position() time.Duration {
+	pos := p.pos
+	if p.playing && !p.paused {
+		pos += time.Duration(float64(time.Since(p.anchor)) * p.speed)
+	}
+	if d := p.Duration(); pos > d {
+		return d
+	}
+	return pos
+}
+
+// Play plays the recording from the current position, until its end or
+// until ctx is done. It returns ErrPlaying if called again meanwhile.
+func (p *Player) Play(ctx context.Context) error {
+	p.mu.Lock()
+	if p.playing {
+		p.mu.Unlock()
+		return ErrPlaying
+	}
+	p.playing, p.anchor = true, time.Now()
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.pos = p.position()
+		p.playing = false
+		p.mu.Unlock()
+	}()
+
+	for {
+		p.wmu.Lock()
+		p.mu.Lock()
+		if p.next == len(p.events) {
+			p.mu.Unlock()
+			p.wmu.Unlock()
+			return nil
+		}
+		ev, paused, changed := p.events[p.next], p.paused, p.changed
+		wait := time.Duration(float64(ev.Time-p.position()) / p.speed)
+		if !paused && wait <= 0 {
+			// The event is played without the state lock, so a blocked
+			// write does not block Pause, Resume and Position.
+			p.next++
+			p.mu.Unlock()
+			err := p.apply(ev)
+			p.wmu.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		p.mu.Unlock()
+		p.wmu.Unlock()
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if !paused {
+			timer = time.NewTimer(wait)
+			fire = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-fire:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// Pause pauses the playback.
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.paused {
+		p.pos = p.position()
+		p.paused = true
+		p.notify()
+	}
+}
+
+// Resume resumes the playback.
+func (p *Player) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused {
+		p.paused, p.anchor = false, time.Now()
+		p.notify()
+	}
+}
+
+// Seek moves the playback to the position d, clamped to the recording.
+// The output up to d is written at once. Seeking backward resets the
+// terminal with RIS, "ESC c", then writes the output from the start.
+func (p *Player) Seek(d time.Duration) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+
+	p.mu.Lock()
+	if d < 0 {
+		d = 0
+	}
+	reset := p.next > 0 && p.events[p.next-1].Time > d
+	if reset {
+		p.next = 0
+	}
+	start := p.next
+	for p.next < len(p.events) && p.events[p.next].Time <= d {
+		p.next++
+	}
+	events := p.events[start:p.next]
+	p.pos, p.anchor = d, time.Now()
+	p.notify()
+	p.mu.Unlock()
+
+	if reset {
+		if _, err := io.WriteString(p.w, "\x1bc"); err != nil {
+			return err
+		}
+		if err := p.resize(p.size); err != nil {
+			return err
+		}
+	}
+	for _, ev := range events {
+		if err := p.apply(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notify wakes up Play to account for a change.
+func (p *Player) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// apply plays the event ev. Events other than output and resize are ignored.
+func (p *Player) apply(ev Event) error {
+	switch ev.Type {
+	case EventOutput:
+		_, err := io.WriteString(p.w, ev.Data)
+		return err
+	case EventResize:
+		if p.tty == nil {
+			return nil
+		}
+		var ws pty.Winsize
+		if _, err := fmt.Sscanf(ev.Data, "%dx%d", &ws.Cols, &ws.Rows); err != nil {
+			return fmt.Errorf("record: invalid resize event %q: %w", ev.Data, err)
+		}
+		return p.resize(&ws)
+	}
+	return nil
+}
+
+// resize resizes the terminal set by WithSetsize to ws, if any.
+func (p *Player) resize(ws *pty.Winsize) error {
+	if p.tty == nil || ws == nil {
+		return nil
+	}
+	return pty.Setsize(p.tty, ws)
+}
@@ -0,0 +1,267 @@
+//go:build !windows
+// +build !windows
+
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+const testCast = `{"version": 2, "width": 20, "height": 5, "idle_time_limit": 0.5}
+[0.0, "o", "a"]
+[0.1, "i", "x"]
+[0.2, "o", "b"]
+
+[10.0, "m", ""]
+[10.1, "o", "c"]
+[10.1, "r", "30x6"]
+`
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestPlayer(t *testing.T, recording string, w io.Writer, opts ...PlayerOption) *Player {
+	t.Helper()
+
+	d, err := NewDecoder(strings.NewReader(recording))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewDecoder: %s.", err)
+	}
+	p, err := NewPlayer(d, w, opts...)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewPlayer: %s.", err)
+	}
+	return p
+}
+
+func TestPlayer(t *testing.T) {
+	t.Parallel()
+
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Fatalf("Unexpected error from Open: %s.", err)
+	}
+	defer func() { _, _ = ptmx.Close(), tty.Close() }() // Best effort.
+
+	var out bytes.Buffer
+	p := newTestPlayer(t, testCast, &out, WithSpeed(2), WithSetsize(ptmx))
+	if ws, err := pty.GetsizeFull(tty); err != nil || ws.Rows != 5 || ws.Cols != 20 {
+		t.Errorf("Unexpected initial size: %+v, %v.", ws, err)
+	}
+	// The 9.8s pause is capped to 0.5s.
+	if d := p.Duration(); d != 800*time.Millisecond {
+		t.Errorf("Unexpected duration: %s.", d)
+	}
+
+	start := time.Now()
+	if err := p.Play(context.Background()); err != nil {
+		t.Fatalf("Unexpected error from Play: %s.", err)
+	}
+	if d := time.Since(start); d < 400*time.Millisecond || d > 5*time.Second {
+		t.Errorf("Unexpected playback time at speed 2: %s.", d)
+	}
+	if out.String() != "abc" {
+		t.Errorf("Unexpected output: %q.", out.String())
+	}
+	if ws, err := pty.GetsizeFull(tty); err != nil || ws.Rows != 6 || ws.Cols != 30 {
+		t.Errorf("Unexpected size: %+v, %v.", ws, err)
+	}
+	if pos := p.Position(); pos != p.Duration() {
+		t.Errorf("Unexpected position at the end: %s.", pos)
+	}
+}
+
+func TestPlayerSeek(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := newTestPlayer(t, testCast, &out, WithIdleTimeLimit(0))
+	if d := p.Duration(); d != 10100*time.Millisecond {
+		t.Errorf("Unexpected duration without idle time limit: %s.", d)
+	}
+
+	for _, tc := range []struct {
+		pos    time.Duration
+		output string
+	}{
+		{200 * time.Millisecond, "ab"},
+		{time.Minute, "abc"},
+		{100 * time.Millisecond, "abc\x1bca"},
+	} {
+		if err := p.Seek(tc.pos); err != nil {
+			t.Fatalf("Unexpected error from Seek: %s.", err)
+		}
+		if out.String() != tc.output {
+			t.Errorf("Unexpected output after seeking to %s: %q != %q.", tc.pos, out.String(), tc.output)
+		}
+	}
+	if pos := p.Position(); pos != 100*time.Millisecond {
+		t.Errorf("Unexpected position: %s.", pos)
+	}
+}
+
+func TestPlayerPause(t *testing.T) {
+	t.Parallel()
+
+	var out syncBuffer
+	p := newTestPlayer(t, testCast, &out)
+	p.Pause()
+
+	errc := make(chan error, 1)
+	go func() { errc <- p.Play(context.Background()) }()
+	time.Sleep(300 * time.Millisecond)
+	if out.String() != "" || p.Position() != 0 {
+		t.Errorf("Unexpected playback while paused: %q at %s.", out.String(), p.Position())
+	}
+
+	p.Resume()
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error from Play: %s.", err)
+	}
+	if out.String() != "abc" {
+		t.Errorf("Unexpected output: %q.", out.String())
+	}
+}
+
+func TestPlayerCancel(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPlayer(t, testCast, io.Discard, WithIdleTimeLimit(0))
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := p.Play(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Unexpected error from Play: %v.", err)
+	}
+	if pos := p.Position(); pos < 200*time.Millisecond || pos > 5*time.Second {
+		t.Errorf("Unexpected position after cancel: %s.", pos)
+	}
+}
+
+// blockedWriter blocks the writes until release is closed.
+type blockedWriter struct {
+	writing chan struct{} // Receives a value on each write.
+	release chan struct{}
+}
+
+func (w *blockedWriter) Write(p []byte) (int, error) {
+	w.writing <- struct{}{}
+	<-w.release
+	return len(p), nil
+}
+
+func TestPlayerBlocked(t *testing.T) {
+	t.Parallel()
+
+	w := &blockedWriter{writing: make(chan struct{}, 16), release: make(chan struct{})}
+	p := newTestPlayer(t, testCast, w)
+
+	errc := make(chan error, 1)
+	go func() { errc <- p.Play(context.Background()) }()
+	<-w.writing
+
+	// The write of "a" is blocked.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Pause()
+		_ = p.Position()
+		p.Resume()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pause blocked by the output.")
+	}
+	if err := p.Play(context.Background()); !errors.Is(err, ErrPlaying) {
+		t.Errorf("Unexpected error from concurrent Play: %v.", err)
+	}
+
+	close(w.release)
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error from Play: %s.", err)
+	}
+}
+
+func TestPlayerTtyrec(t *testing.T) {
+	t.Parallel()
+
+	var rec bytes.Buffer
+	for _, f := range []struct {
+		sec, usec uint32
+		data      string
+	}{
+		{1000, 999000, "hello "},
+		{1001, 100000, "world"},
+	} {
+		_ = binary.Write(&rec, binary.LittleEndian, [3]uint32{f.sec, f.usec, uint32(len(f.data))})
+		rec.WriteString(f.data)
+	}
+
+	var out bytes.Buffer
+	p := newTestPlayer(t, rec.String(), &out)
+	if d := p.Duration(); d != 101*time.Millisecond {
+		t.Errorf("Unexpected duration: %s.", d)
+	}
+	if err := p.Play(context.Background()); err != nil {
+		t.Fatalf("Unexpected error from Play: %s.", err)
+	}
+	if out.String() != "hello world" {
+		t.Errorf("Unexpected output: %q.", out.String())
+	}
+
+	// Truncated frame.
+	d, err := NewDecoder(bytes.NewReader(rec.Bytes()[:rec.Len()-1]))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewDecoder: %s.", err)
+	}
+	if _, err := NewPlayer(d, io.Discard); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Unexpected error from NewPlayer with a truncated frame: %v.", err)
+	}
+}
+
+func TestAsciicastDecoderErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name, recording, err string
+	}{
+		{"version", `{"version": 1}`, "unsupported version 1"},
+		{"header", "{\n", "invalid header"},
+		{"event", "{\"version\": 2}\n[1, \"o\"]\n", "invalid event on line 2"},
+	} {
+		_, err := NewDecoder(strings.NewReader(tc.recording))
+		if err == nil {
+			var d Decoder
+			d, _ = NewDecoder(strings.NewReader(tc.recording))
+			_, err = NewPlayer(d, io.Discard)
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Unexpected %s error: %v.", tc.name, err)
+		}
+	}
+}
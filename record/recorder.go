@@ -0,0 +1,145 @@
+// Package record records pty sessions, see Recorder, and replays them, see
+// Player. The supported formats are asciicast v2 of asciinema, ttyrec and
+// the typescript and advanced timing files of script(1).
+package record
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithInput records the input written through the Recorder.
+func WithInput() RecorderOption {
+	return func(r *Recorder) { r.input = true }
+}
+
+// WithEnv sets the environment variables recorded in the header. The default
+// is SHELL and TERM, from the environment of the process.
+func WithEnv(env map[string]string) RecorderOption {
+	return func(r *Recorder) { r.env = env }
+}
+
+// WithTitle sets the title recorded in the header.
+func WithTitle(title string) RecorderOption {
+	return func(r *Recorder) { r.title = title }
+}
+
+// WithCommand sets the command recorded in the header.
+func WithCommand(command string) RecorderOption {
+	return func(r *Recorder) { r.command = command }
+}
+
+// Recorder records a pty session. It wraps the pty master f: the output read
+// from f is recorded as "o" events and, optionally, the input written to f
+// as "i" events. The resizes done with Setsize are recorded as "r" events.
+//
+// Read and Write may be called concurrently.
+type Recorder struct {
+	f       *os.File
+	input   bool
+	env     map[string]string
+	title   string
+	command string
+	now     func() time.Time
+
+	mu    sync.Mutex
+	enc   Encoder
+	start time.Time
+}
+
+// NewRecorder writes the asciicast header to w, with the size of f, and
+// returns a Recorder recording the session of the pty master f to w.
+func NewRecorder(f *os.File, w io.Writer, opts ...RecorderOption) (*Recorder, error) {
+	r := newRecorder(f, opts)
+	ws, err := pty.GetsizeFull(f)
+	if err != nil {
+		return nil, err
+	}
+	r.enc, err = NewAsciicastEncoder(w, &Header{
+		Version:   2,
+		Width:     int(ws.Cols),
+		Height:    int(ws.Rows),
+		Timestamp: r.start.Unix(),
+		Command:   r.command,
+		Title:     r.title,
+		Env:       r.env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewEncoderRecorder returns a Recorder recording the session of the pty
+// master f with enc, e.g. a TtyrecEncoder. The header options, WithEnv,
+// WithTitle and WithCommand, are ignored.
+func NewEncoderRecorder(f *os.File, enc Encoder, opts ...RecorderOption) *Recorder {
+	r := newRecorder(f, opts)
+	r.enc = enc
+	return r
+}
+
+func newRecorder(f *os.File, opts []RecorderOption) *Recorder {
+	r := &Recorder{f: f, now: time.Now}
+	for _, name := range []string{"SHELL", "TERM"} {
+		if v, ok := os.LookupEnv(name); ok {
+			if r.env == nil {
+				r.env = map[string]string{}
+			}
+			r.env[name] = v
+		}
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.start = r.now()
+	return r
+}
+
+// Read reads the output of the program from the pty and records it.
+// An error recording the output is returned after the output read.
+func (r *Recorder) Read(b []byte) (int, error) {
+	n, err := r.f.Read(b)
+	if n > 0 {
+		if rerr := r.record(EventOutput, b[:n]); err == nil {
+			err = rerr
+		}
+	}
+	return n, err
+}
+
+// Write writes b to the pty, as input of the program, and records it if
+// enabled, see WithInput.
+func (r *Recorder) Write(b []byte) (int, error) {
+	n, err := r.f.Write(b)
+	if n > 0 && r.input {
+		if rerr := r.record(EventInput, b[:n]); err == nil {
+			err = rerr
+		}
+	}
+	return n, err
+}
+
+// Setsize resizes the pty and records the new size. See pty.Setsize.
+func (r *Recorder) Setsize(ws *pty.Winsize) error {
+	if err := pty.Setsize(r.f, ws); err != nil {
+		return err
+	}
+
+	return r.record(EventResize, []byte(fmt.Sprintf("%dx%d", ws.Cols, ws.Rows)))
+}
+
+// record records b as an event of type typ.
+func (r *Recorder) record(typ string, b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(Event{Time: r.now().Sub(r.start), Type: typ, Data: string(b)})
+}
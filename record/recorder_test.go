@@ -0,0 +1,129 @@
+//go:build !windows
+// +build !windows
+
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// parseCast parses an asciicast v2 recording.
+func parseCast(t *testing.T, cast string) (*Header, []Event) {
+	t.Helper()
+
+	s := bufio.NewScanner(strings.NewReader(cast))
+	if !s.Scan() {
+		t.Fatal("Missing header.")
+	}
+	var h Header
+	if err := json.Unmarshal(s.Bytes(), &h); err != nil {
+		t.Fatalf("Unexpected error parsing the header: %s.", err)
+	}
+
+	var events []Event
+	for s.Scan() {
+		var ev [3]interface{}
+		if err := json.Unmarshal(s.Bytes(), &ev); err != nil {
+			t.Fatalf("Unexpected error parsing the event %q: %s.", s.Text(), err)
+		}
+		sec, _ := ev[0].(float64)
+		typ, _ := ev[1].(string)
+		data, _ := ev[2].(string)
+		events = append(events, Event{Time: time.Duration(sec * float64(time.Second)), Type: typ, Data: data})
+	}
+	return &h, events
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("cat")
+	p, err := pty.StartWithSize(c, &pty.Winsize{Rows: 24, Cols: 80})
+	if err != nil {
+		t.Fatalf("Unexpected error from Start: %s.", err)
+	}
+	defer func() { _, _, _ = c.Process.Kill(), c.Wait(), p.Close() }() // Best effort.
+
+	var cast bytes.Buffer
+	r, err := NewRecorder(p, &cast, WithInput(), WithEnv(map[string]string{"TERM": "xterm"}), WithTitle("test"))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewRecorder: %s.", err)
+	}
+
+	if _, err := r.Write([]byte("héllo\n")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	// The tty echoes the input, then cat writes it back.
+	var out []byte
+	for !bytes.Equal(out, []byte("héllo\r\nhéllo\r\n")) {
+		b := make([]byte, 1)
+		if _, err := r.Read(b); err != nil {
+			t.Fatalf("Unexpected error from Read after %q: %s.", out, err)
+		}
+		out = append(out, b...)
+	}
+	if err := r.Setsize(&pty.Winsize{Rows: 30, Cols: 100}); err != nil {
+		t.Fatalf("Unexpected error from Setsize: %s.", err)
+	}
+
+	h, events := parseCast(t, cast.String())
+	if want := (Header{Version: 2, Width: 80, Height: 24, Timestamp: h.Timestamp, Title: "test", Env: map[string]string{"TERM": "xterm"}}); !reflect.DeepEqual(*h, want) {
+		t.Errorf("Unexpected header: %+v.", h)
+	}
+	if d := time.Since(time.Unix(h.Timestamp, 0)); d < 0 || d > time.Minute {
+		t.Errorf("Unexpected timestamp: %d.", h.Timestamp)
+	}
+
+	var types []string
+	var output string
+	for i, ev := range events {
+		if i > 0 && ev.Time < events[i-1].Time {
+			t.Errorf("Unexpected event time: %s < %s.", ev.Time, events[i-1].Time)
+		}
+		types = append(types, ev.Type)
+		if ev.Type == EventOutput {
+			output += ev.Data
+		}
+	}
+	// The output is read byte per byte, the "é" are split across reads.
+	if want := []string{"i", "o", "o", "o", "o", "o", "o", "o", "o", "o", "o", "o", "o", "o", "o", "r"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("Unexpected events: %q != %q.", types, want)
+	}
+	if output != string(out) {
+		t.Errorf("Unexpected output: %q != %q.", output, out)
+	}
+	if events[0].Data != "héllo\n" || events[len(events)-1].Data != "100x30" {
+		t.Errorf("Unexpected events: %+v.", events)
+	}
+}
+
+func TestAsciicastEncoder(t *testing.T) {
+	t.Parallel()
+
+	var cast bytes.Buffer
+	enc, err := NewAsciicastEncoder(&cast, &Header{Version: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewAsciicastEncoder: %s.", err)
+	}
+	for _, b := range []string{"\x1b[1m<a&b>\"", "\xc3", "\xa9\xff"} {
+		if err := enc.Encode(Event{Type: EventOutput, Data: b}); err != nil {
+			t.Fatalf("Unexpected error from Encode: %s.", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(cast.String(), "\n"), "\n")[1:]
+	for i, want := range []string{`"o", "\u001b[1m<a&b>\""]`, `"o", "é�"]`} {
+		if i >= len(lines) || !strings.HasSuffix(lines[i], want) {
+			t.Errorf("Unexpected event %d: %q does not end with %q.", i, lines, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package record
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxTtyrecFrame is the maximum size of the data of a ttyrec frame, to
+// detect corrupted recordings.
+const maxTtyrecFrame = 64 << 20
+
+// TtyrecDecoder reads a ttyrec recording: a sequence of frames made of a
+// header, the Unix time of the frame in seconds and microseconds and the
+// length of the data, as little endian 32-bit integers, followed by the
+// output data.
+type TtyrecDecoder struct {
+	r     io.Reader
+	start time.Time // Time of the first frame.
+}
+
+// NewTtyrecDecoder returns a decoder of the ttyrec recording r.
+func NewTtyrecDecoder(r io.Reader) *TtyrecDecoder {
+	return &TtyrecDecoder{r: r}
+}
+
+// Decode returns the next frame as an output event, timed from the first
+// frame, or io.EOF at the end of the recording.
+func (d *TtyrecDecoder) Decode() (Event, error) {
+	var h [12]byte
+	if _, err := io.ReadFull(d.r, h[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Event{}, fmt.Errorf("ttyrec: truncated header: %w", err)
+		}
+		return Event{}, err
+	}
+	sec := binary.LittleEndian.Uint32(h[0:])
+	usec := binary.LittleEndian.Uint32(h[4:])
+	n := binary.LittleEndian.Uint32(h[8:])
+	if n > maxTtyrecFrame {
+		return Event{}, fmt.Errorf("ttyrec: frame too large: %d bytes", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Event{}, fmt.Errorf("ttyrec: truncated frame: %w", err)
+	}
+
+	t := time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+	if d.start.IsZero() {
+		d.start = t
+	}
+	return Event{Time: t.Sub(d.start), Type: EventOutput, Data: string(data)}, nil
+}
+
+// TtyrecEncoder writes a ttyrec recording. Only the output is recorded.
+type TtyrecEncoder struct {
+	w     io.Writer
+	start time.Time
+}
+
+// NewTtyrecEncoder returns an encoder writing a ttyrec recording to w, with
+// the events timed from start.
+func NewTtyrecEncoder(w io.Writer, start time.Time) *TtyrecEncoder {
+	return &TtyrecEncoder{w: w, start: start}
+}
+
+// Encode writes the output event ev as a frame. Other events are dropped.
+func (e *TtyrecEncoder) Encode(ev Event) error {
+	if ev.Type != EventOutput || ev.Data == "" {
+		return nil
+	}
+	t := e.start.Add(ev.Time)
+	b := make([]byte, 12, 12+len(ev.Data))
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Unix()))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Nanosecond()/int(time.Microsecond)))
+	binary.LittleEndian.PutUint32(b[8:], uint32(len(ev.Data)))
+	_, err := e.w.Write(append(b, ev.Data...))
+	return err
+}
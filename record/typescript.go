@@ -0,0 +1,254 @@
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// typescriptTime is the layout of the times written by script.
+const typescriptTime = "2006-01-02 15:04:05-07:00"
+
+// typescriptStart starts the header line of a typescript.
+const typescriptStart = "Script started on "
+
+// TypescriptDecoder reads a typescript and its timing file, as written by
+// the script(1) command of util-linux with --log-timing. Both the classic
+// timing format, "DELAY SIZE" lines for the output, and the advanced one
+// are supported. The advanced format has a line per event: "O DELAY SIZE"
+// for the output, "I DELAY SIZE" for the input, "S DELAY SIGNAL [INFO]" for
+// the signals and "H DELAY NAME VALUE" for the information of the header.
+// The delays are in seconds, since the previous event. The input and output
+// are read from the typescript, which must log both with --log-io if the
+// input is logged.
+type TypescriptDecoder struct {
+	data    *bufio.Reader
+	timing  *bufio.Scanner
+	header  Header
+	time    time.Duration
+	line    int
+	pending bool // The current timing line has been read with the header.
+}
+
+// NewTypescriptDecoder reads the header lines of the timing file and returns
+// a decoder of the typescript and its timing.
+func NewTypescriptDecoder(typescript, timing io.Reader) (*TypescriptDecoder, error) {
+	d := &TypescriptDecoder{
+		data:   bufio.NewReader(typescript),
+		timing: bufio.NewScanner(timing),
+		header: Header{Version: 2},
+	}
+
+	// The first line of the typescript is written by script, it is not
+	// part of the output.
+	if b, _ := d.data.Peek(len(typescriptStart)); string(b) == typescriptStart {
+		if _, err := d.data.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("typescript: invalid header: %w", err)
+		}
+	}
+
+	for d.timing.Scan() {
+		d.line++
+		if !strings.HasPrefix(d.timing.Text(), "H ") {
+			d.pending = true
+			break
+		}
+		if _, _, err := d.parse(d.timing.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.timing.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Header returns the information of the header of the timing file: the size
+// of the terminal, the start time, the command, TERM and SHELL.
+func (d *TypescriptDecoder) Header() *Header {
+	return &d.header
+}
+
+// Decode returns the next event, or io.EOF at the end of the recording.
+// The SIGWINCH signals are returned as resize events, the other signals are
+// dropped.
+func (d *TypescriptDecoder) Decode() (Event, error) {
+	for {
+		if !d.pending {
+			if !d.timing.Scan() {
+				if err := d.timing.Err(); err != nil {
+					return Event{}, err
+				}
+				return Event{}, io.EOF
+			}
+			d.line++
+		}
+		d.pending = false
+
+		ev, ok, err := d.parse(d.timing.Text())
+		if err != nil || ok {
+			return ev, err
+		}
+	}
+}
+
+// parse parses a line of the timing file, and returns the event it
+// describes, if any.
+func (d *TypescriptDecoder) parse(line string) (ev Event, ok bool, err error) {
+	if strings.TrimSpace(line) == "" {
+		return Event{}, false, nil
+	}
+	typ, f := "O", strings.SplitN(line, " ", 2) // Classic format.
+	if c := line[0]; c >= 'A' && c <= 'Z' {
+		f = strings.SplitN(line, " ", 4)
+		typ, f = f[0], f[1:]
+	}
+	if len(f) < 2 {
+		return Event{}, false, fmt.Errorf("typescript: invalid timing on line %d: %q", d.line, line)
+	}
+	delay, err := strconv.ParseFloat(f[0], 64)
+	if err != nil || delay < 0 {
+		return Event{}, false, fmt.Errorf("typescript: invalid delay on line %d: %q", d.line, line)
+	}
+	d.time += time.Duration(math.Round(delay*1e6)) * time.Microsecond
+
+	switch typ {
+	case "O", "I":
+		n, err := strconv.Atoi(f[1])
+		if err != nil || n < 0 {
+			return Event{}, false, fmt.Errorf("typescript: invalid size on line %d: %q", d.line, line)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(d.data, data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return Event{}, false, fmt.Errorf("typescript: truncated data on line %d: %w", d.line, err)
+		}
+		ev := Event{Time: d.time, Type: EventOutput, Data: string(data)}
+		if typ == "I" {
+			ev.Type = EventInput
+		}
+		return ev, true, nil
+	case "S":
+		if f[1] != "SIGWINCH" || len(f) < 3 {
+			return Event{}, false, nil
+		}
+		var rows, cols int
+		if _, err := fmt.Sscanf(f[2], "ROWS=%d COLS=%d", &rows, &cols); err != nil {
+			return Event{}, false, fmt.Errorf("typescript: invalid SIGWINCH on line %d: %q", d.line, line)
+		}
+		return Event{Time: d.time, Type: EventResize, Data: fmt.Sprintf("%dx%d", cols, rows)}, true, nil
+	case "H":
+		if len(f) == 3 {
+			d.info(f[1], f[2])
+		}
+	}
+	return Event{}, false, nil
+}
+
+// info records the header information name.
+func (d *TypescriptDecoder) info(name, value string) {
+	h := &d.header
+	switch name {
+	case "START_TIME":
+		if t, err := time.Parse(typescriptTime, value); err == nil {
+			h.Timestamp = t.Unix()
+		}
+	case "COLUMNS":
+		h.Width, _ = strconv.Atoi(value)
+	case "LINES":
+		h.Height, _ = strconv.Atoi(value)
+	case "COMMAND":
+		h.Command = value
+	case "TERM", "SHELL":
+		if h.Env == nil {
+			h.Env = map[string]string{}
+		}
+		h.Env[name] = value
+	}
+}
+
+// TypescriptEncoder writes a typescript and its timing file in the advanced
+// format, see TypescriptDecoder. The markers are dropped.
+type TypescriptEncoder struct {
+	data   io.Writer
+	timing io.Writer
+	last   time.Duration // Time of the previous event.
+}
+
+// NewTypescriptEncoder writes the header line of the typescript and the
+// header information of the timing file, from h, and returns an encoder of
+// the events following them.
+func NewTypescriptEncoder(typescript, timing io.Writer, h *Header) (*TypescriptEncoder, error) {
+	start := time.Now()
+	if h.Timestamp != 0 {
+		start = time.Unix(h.Timestamp, 0)
+	}
+	info := [][2]string{
+		{"START_TIME", start.Format(typescriptTime)},
+		{"COMMAND", h.Command},
+		{"TERM", h.Env["TERM"]},
+		{"SHELL", h.Env["SHELL"]},
+	}
+	if h.Width > 0 && h.Height > 0 {
+		info = append(info, [2]string{"COLUMNS", strconv.Itoa(h.Width)}, [2]string{"LINES", strconv.Itoa(h.Height)})
+	}
+
+	var attrs, lines []string
+	for _, i := range info {
+		if i[1] == "" {
+			continue
+		}
+		if i[0] != "START_TIME" && i[0] != "SHELL" {
+			attrs = append(attrs, i[0]+`="`+i[1]+`"`)
+		}
+		lines = append(lines, "H 0.000000 "+i[0]+" "+i[1]+"\n")
+	}
+	if _, err := fmt.Fprintf(typescript, "%s%s [%s]\n", typescriptStart, start.Format(typescriptTime), strings.Join(attrs, " ")); err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(timing, strings.Join(lines, "")); err != nil {
+		return nil, err
+	}
+	return &TypescriptEncoder{data: typescript, timing: timing}, nil
+}
+
+// Encode writes the output and input of ev to the typescript, and ev to the
+// timing file.
+func (e *TypescriptEncoder) Encode(ev Event) error {
+	var line string
+	delay := (ev.Time - e.last).Truncate(time.Microsecond)
+	if delay < 0 {
+		delay = 0
+	}
+	switch ev.Type {
+	case EventOutput, EventInput:
+		if ev.Data == "" {
+			return nil
+		}
+		if _, err := io.WriteString(e.data, ev.Data); err != nil {
+			return err
+		}
+		typ := "O"
+		if ev.Type == EventInput {
+			typ = "I"
+		}
+		line = fmt.Sprintf("%s %d.%06d %d\n", typ, delay/time.Second, delay%time.Second/time.Microsecond, len(ev.Data))
+	case EventResize:
+		var rows, cols int
+		if _, err := fmt.Sscanf(ev.Data, "%dx%d", &cols, &rows); err != nil {
+			return fmt.Errorf("typescript: invalid resize event %q: %w", ev.Data, err)
+		}
+		line = fmt.Sprintf("S %d.%06d SIGWINCH ROWS=%d COLS=%d\n", delay/time.Second, delay%time.Second/time.Microsecond, rows, cols)
+	default:
+		return nil
+	}
+	e.last += delay
+	_, err := io.WriteString(e.timing, line)
+	return err
+}
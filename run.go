@@ -25,7 +25,23 @@ func Start(cmd *exec.Cmd) (*os.File, error) {
 // This should generally not be needed. Used in some edge cases where it is needed to create a pty
 // without a controlling terminal.
 func StartWithAttrs(c *exec.Cmd, sz *Winsize, attrs *syscall.SysProcAttr) (*os.File, error) {
-	pty, tty, err := Open()
+	return startWithAttrs(c, sz, nil, attrs)
+}
+
+// startWithAttrs implements StartWithAttrs. If mode is not nil, it is applied
+// to the tty before starting the command so the child inherits the terminal mode.
+func startWithAttrs(c *exec.Cmd, sz *Winsize, mode func(*os.File) (*Termios, error), attrs *syscall.SysProcAttr) (*os.File, error) {
+	p, err := startPty(c, OpenOptions{}, sz, mode, attrs)
+	if err != nil {
+		return nil, err
+	}
+	return p.master, nil
+}
+
+// startPty opens a Pty as described by opts and starts c with the tty as c.Stdin,
+// c.Stdout and c.Stderr when not already set. The tty is closed once the command is started.
+func startPty(c *exec.Cmd, opts OpenOptions, sz *Winsize, mode func(*os.File) (*Termios, error), attrs *syscall.SysProcAttr) (*Pty, error) {
+	pty, tty, err := openWithOptions(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +53,12 @@ func StartWithAttrs(c *exec.Cmd, sz *Winsize, attrs *syscall.SysProcAttr) (*os.F
 			return nil, err
 		}
 	}
+	if mode != nil {
+		if _, err := mode(tty); err != nil {
+			_ = pty.Close() // Best effort.
+			return nil, err
+		}
+	}
 	if c.Stdout == nil {
 		c.Stdout = tty
 	}
@@ -53,5 +75,5 @@ func StartWithAttrs(c *exec.Cmd, sz *Winsize, attrs *syscall.SysProcAttr) (*os.F
 		_ = pty.Close() // Best effort.
 		return nil, err
 	}
-	return pty, err
+	return &Pty{master: pty, name: tty.Name(), cmd: c, exited: make(chan struct{})}, nil
 }
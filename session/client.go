@@ -0,0 +1,185 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// Client is a client attached to a session.
+//
+// Read may be called concurrently with the other methods.
+type Client struct {
+	conn net.Conn
+
+	wmu sync.Mutex // Serializes the frames written.
+
+	data []byte // Output not read yet.
+	code int
+	exit bool // The session ended with code.
+	err  error
+}
+
+// Dial connects to the server listening on the Unix domain socket path, and
+// attaches to a session, see Attach.
+func Dial(path string, req *AttachRequest) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Attach(conn, req)
+	if err != nil {
+		_ = conn.Close() // Best effort.
+		return nil, err
+	}
+	return c, nil
+}
+
+// Attach attaches conn, connected to a server, to the session of req. The
+// output starts with the output redrawing the screen.
+func Attach(conn net.Conn, req *AttachRequest) (*Client, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteFrame(conn, Frame{Type: TypeAttach, Payload: b}); err != nil {
+		return nil, err
+	}
+	f, err := ReadFrame(conn)
+	switch {
+	case err != nil:
+		return nil, err
+	case f.Type == TypeError:
+		return nil, remoteError(f)
+	case f.Type != TypeOK:
+		return nil, fmt.Errorf("session: unexpected frame %d", f.Type)
+	}
+	return &Client{conn: conn}, nil
+}
+
+// List returns the names of the sessions of the server listening on the
+// Unix domain socket path.
+func List(path string) ([]string, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }() // Best effort.
+
+	if err := WriteFrame(conn, Frame{Type: TypeList}); err != nil {
+		return nil, err
+	}
+	f, err := ReadFrame(conn)
+	switch {
+	case err != nil:
+		return nil, err
+	case f.Type == TypeError:
+		return nil, remoteError(f)
+	case f.Type != TypeList:
+		return nil, fmt.Errorf("session: unexpected frame %d", f.Type)
+	case len(f.Payload) == 0:
+		return nil, nil
+	}
+	return strings.Split(string(f.Payload), "\n"), nil
+}
+
+// remoteError returns the error of the error frame f.
+func remoteError(f Frame) error {
+	msg := string(f.Payload)
+	for _, err := range []error{ErrNotFound, ErrExists, ErrFrameTooLarge} {
+		if strings.HasPrefix(msg, err.Error()) {
+			return fmt.Errorf("%w%s", err, strings.TrimPrefix(msg, err.Error()))
+		}
+	}
+	return errors.New(msg)
+}
+
+// Read reads the output of the session. It returns io.EOF once the session
+// ended, see ExitCode, or the client is detached.
+func (c *Client) Read(b []byte) (int, error) {
+	for len(c.data) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		f, err := ReadFrame(c.conn)
+		switch {
+		case errors.Is(err, net.ErrClosed):
+			c.err = io.EOF
+		case err != nil:
+			c.err = err
+		case f.Type == TypeData:
+			c.data = f.Payload
+		case f.Type == TypeExit:
+			c.code, c.err = f.ExitCode()
+			c.exit = c.err == nil
+			if c.err == nil {
+				c.err = io.EOF
+			}
+		case f.Type == TypeError:
+			c.err = remoteError(f)
+		}
+	}
+	n := copy(b, c.data)
+	c.data = c.data[n:]
+	return n, nil
+}
+
+// ExitCode returns the exit code of the program, once Read returned io.EOF
+// because the session ended. See Session.ExitCode.
+func (c *Client) ExitCode() (code int, ok bool) {
+	return c.code, c.exit
+}
+
+// Write writes b as input of the session.
+func (c *Client) Write(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		chunk := b[n:]
+		if len(chunk) > MaxPayload {
+			chunk = chunk[:MaxPayload]
+		}
+		if err := c.send(Frame{Type: TypeData, Payload: chunk}); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+// Resize resizes the terminal of the session.
+func (c *Client) Resize(ws *pty.Winsize) error {
+	return c.send(ResizeFrame(ws))
+}
+
+// Signal sends sig to the foreground process group of the session.
+func (c *Client) Signal(sig syscall.Signal) error {
+	return c.send(SignalFrame(sig))
+}
+
+// Detach detaches the client from the session, which keeps running, and
+// closes the connection.
+func (c *Client) Detach() error {
+	err := c.send(Frame{Type: TypeDetach})
+	if e := c.conn.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// Close closes the connection, which detaches the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) send(f Frame) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return WriteFrame(c.conn, f)
+}
@@ -0,0 +1,178 @@
+// Package session hosts named pty sessions which survive their clients, like
+// tmux or dtach: a Server keeps the programs running and their ptys open,
+// while clients attach and detach over a Unix domain socket, see Dial.
+//
+// Clients and server exchange frames, see Frame. A client first sends an
+// attach frame, and then its input, resizes and signals. The server replies
+// with the output, starting with the output redrawing the screen, and the
+// exit status of the program once it ends.
+package session
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// MaxPayload is the maximum size of the payload of a frame.
+const MaxPayload = 1 << 20
+
+// ErrFrameTooLarge is returned when reading or writing a frame larger than
+// MaxPayload.
+var ErrFrameTooLarge = errors.New("session: frame too large")
+
+// Type is the type of a frame.
+type Type byte
+
+// Frame types, and their payload.
+const (
+	// TypeAttach is sent by a client to attach to a session, with an
+	// AttachRequest encoded in JSON.
+	TypeAttach Type = iota + 1
+
+	// TypeList is sent by a client to list the sessions, and by the server
+	// with their names, one per line.
+	TypeList
+
+	// TypeOK is sent by the server once attached, without payload.
+	TypeOK
+
+	// TypeError is sent by the server with an error message, before closing
+	// the connection.
+	TypeError
+
+	// TypeData is sent by a client with its input, and by the server with
+	// the output.
+	TypeData
+
+	// TypeResize is sent by a client to resize the terminal, see
+	// ResizeFrame.
+	TypeResize
+
+	// TypeSignal is sent by a client to signal the foreground process group,
+	// see SignalFrame.
+	TypeSignal
+
+	// TypeDetach is sent by a client to detach, without payload.
+	TypeDetach
+
+	// TypeExit is sent by the server with the exit status of the program,
+	// see ExitFrame, before closing the connection.
+	TypeExit
+)
+
+// Frame is a message of the protocol: a type and a payload, written as the
+// type byte, the length of the payload as a big endian 32-bit integer and
+// the payload.
+type Frame struct {
+	Type    Type
+	Payload []byte
+}
+
+// AttachRequest is the payload of an attach frame.
+type AttachRequest struct {
+	// Name is the name of the session.
+	Name string `json:"name"`
+
+	// Command is the command started in the session if it does not exist,
+	// with its arguments. The attach fails if the session does not exist and
+	// Command is empty.
+	Command []string `json:"command,omitempty"`
+
+	// Size resizes the terminal, if set.
+	Size *pty.Winsize `json:"size,omitempty"`
+
+	// ReadOnly attaches the client as a viewer, whose input is discarded.
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+// ReadFrame reads a frame from r.
+func ReadFrame(r io.Reader) (Frame, error) {
+	var h [5]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return Frame{}, err
+	}
+	n := binary.BigEndian.Uint32(h[1:])
+	if n > MaxPayload {
+		return Frame{}, ErrFrameTooLarge
+	}
+	f := Frame{Type: Type(h[0]), Payload: make([]byte, n)}
+	if _, err := io.ReadFull(r, f.Payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, err
+	}
+	return f, nil
+}
+
+// WriteFrame writes f to w, in a single write.
+func WriteFrame(w io.Writer, f Frame) error {
+	if len(f.Payload) > MaxPayload {
+		return ErrFrameTooLarge
+	}
+	b := make([]byte, 5, 5+len(f.Payload))
+	b[0] = byte(f.Type)
+	binary.BigEndian.PutUint32(b[1:], uint32(len(f.Payload)))
+	_, err := w.Write(append(b, f.Payload...))
+	return err
+}
+
+// ResizeFrame returns a resize frame to ws: the rows, columns, width and
+// height as big endian 16-bit integers.
+func ResizeFrame(ws *pty.Winsize) Frame {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint16(b[0:], ws.Rows)
+	binary.BigEndian.PutUint16(b[2:], ws.Cols)
+	binary.BigEndian.PutUint16(b[4:], ws.X)
+	binary.BigEndian.PutUint16(b[6:], ws.Y)
+	return Frame{Type: TypeResize, Payload: b}
+}
+
+// Winsize returns the size of the resize frame f.
+func (f Frame) Winsize() (*pty.Winsize, error) {
+	if f.Type != TypeResize || len(f.Payload) != 8 {
+		return nil, fmt.Errorf("session: invalid resize frame: %d %q", f.Type, f.Payload)
+	}
+	return &pty.Winsize{
+		Rows: binary.BigEndian.Uint16(f.Payload[0:]),
+		Cols: binary.BigEndian.Uint16(f.Payload[2:]),
+		X:    binary.BigEndian.Uint16(f.Payload[4:]),
+		Y:    binary.BigEndian.Uint16(f.Payload[6:]),
+	}, nil
+}
+
+// SignalFrame returns a signal frame of sig, as a big endian 32-bit integer.
+func SignalFrame(sig syscall.Signal) Frame {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(sig))
+	return Frame{Type: TypeSignal, Payload: b}
+}
+
+// Signal returns the signal of the signal frame f.
+func (f Frame) Signal() (syscall.Signal, error) {
+	if f.Type != TypeSignal || len(f.Payload) != 4 {
+		return 0, fmt.Errorf("session: invalid signal frame: %d %q", f.Type, f.Payload)
+	}
+	return syscall.Signal(binary.BigEndian.Uint32(f.Payload)), nil
+}
+
+// ExitFrame returns an exit frame of the exit code, as a big endian 32-bit
+// integer.
+func ExitFrame(code int) Frame {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(int32(code)))
+	return Frame{Type: TypeExit, Payload: b}
+}
+
+// ExitCode returns the exit code of the exit frame f.
+func (f Frame) ExitCode() (int, error) {
+	if f.Type != TypeExit || len(f.Payload) != 4 {
+		return 0, fmt.Errorf("session: invalid exit frame: %d %q", f.Type, f.Payload)
+	}
+	return int(int32(binary.BigEndian.Uint32(f.Payload))), nil
+}
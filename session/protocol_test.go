@@ -0,0 +1,60 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/creack/pty"
+)
+
+func TestFrame(t *testing.T) {
+	t.Parallel()
+
+	ws := &pty.Winsize{Rows: 24, Cols: 80, X: 640, Y: 480}
+	var buf bytes.Buffer
+	for _, f := range []Frame{
+		{Type: TypeData, Payload: []byte("hello")},
+		ResizeFrame(ws),
+		SignalFrame(syscall.SIGINT),
+		ExitFrame(-1),
+	} {
+		if err := WriteFrame(&buf, f); err != nil {
+			t.Fatalf("Unexpected error from WriteFrame: %s.", err)
+		}
+	}
+
+	f, err := ReadFrame(&buf)
+	if err != nil || f.Type != TypeData || string(f.Payload) != "hello" {
+		t.Errorf("Unexpected data frame: %+v, %v.", f, err)
+	}
+	f, _ = ReadFrame(&buf)
+	if got, err := f.Winsize(); err != nil || !reflect.DeepEqual(got, ws) {
+		t.Errorf("Unexpected size: %+v, %v.", got, err)
+	}
+	f, _ = ReadFrame(&buf)
+	if got, err := f.Signal(); err != nil || got != syscall.SIGINT {
+		t.Errorf("Unexpected signal: %v, %v.", got, err)
+	}
+	f, _ = ReadFrame(&buf)
+	if got, err := f.ExitCode(); err != nil || got != -1 {
+		t.Errorf("Unexpected exit code: %d, %v.", got, err)
+	}
+	if _, err := ReadFrame(&buf); err != io.EOF {
+		t.Errorf("Unexpected error at the end: %v.", err)
+	}
+
+	// Truncated and too large frames.
+	if _, err := ReadFrame(bytes.NewReader([]byte{byte(TypeData), 0, 0, 0, 5, 'a'})); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Unexpected error from a truncated frame: %v.", err)
+	}
+	if _, err := ReadFrame(bytes.NewReader([]byte{byte(TypeData), 0xff, 0, 0, 0})); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Unexpected error from a too large frame: %v.", err)
+	}
+	if _, err := (Frame{Type: TypeExit}).ExitCode(); err == nil {
+		t.Error("Expected error from an invalid exit frame.")
+	}
+}
@@ -0,0 +1,355 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/creack/pty"
+	"github.com/creack/pty/share"
+	"github.com/creack/pty/vt"
+)
+
+// ErrNotFound is returned when attaching to a session which does not exist,
+// without a command to start it.
+var ErrNotFound = errors.New("session: no such session")
+
+// ErrExists is returned by Server.Start when the session already exists.
+var ErrExists = errors.New("session: session already exists")
+
+// defaultSize is the size of the sessions started without one.
+var defaultSize = pty.Winsize{Rows: 24, Cols: 80}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithBufferSize sets the size of the output buffer of each client. The
+// clients which do not read their output fast enough are disconnected, and
+// may attach again. See share.WithBufferSize.
+func WithBufferSize(n int) ServerOption {
+	return func(s *Server) { s.bufSize = n }
+}
+
+// Server hosts named sessions, see Serve.
+type Server struct {
+	bufSize int
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewServer returns a Server without sessions.
+func NewServer(opts ...ServerOption) *Server {
+	s := &Server{
+		bufSize:  share.DefaultBufferSize,
+		sessions: map[string]*Session{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Serve accepts the clients on l, usually a Unix domain socket, until l is
+// closed. It returns the error of Accept.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Start starts cmd in a new session named name, in a pty of size ws, 80x24
+// if nil. The session ends once the program exits and the pty is closed by
+// all the processes of the session.
+func (s *Server) Start(name string, cmd *exec.Cmd, ws *pty.Winsize) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.start(name, cmd, ws)
+}
+
+func (s *Server) start(name string, cmd *exec.Cmd, ws *pty.Winsize) (*Session, error) {
+	if _, ok := s.sessions[name]; ok {
+		return nil, ErrExists
+	}
+	if ws == nil || ws.Rows == 0 || ws.Cols == 0 {
+		ws = &defaultSize
+	}
+	p, err := pty.StartPty(cmd, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{
+		name:   name,
+		p:      p,
+		screen: vt.NewScreen(int(ws.Rows), int(ws.Cols)),
+		done:   make(chan struct{}),
+	}
+	sess.bc = share.NewBroadcaster(p.Master(),
+		share.WithBufferSize(s.bufSize),
+		share.WithPolicy(share.PolicyDisconnect),
+		share.WithSnapshot(redrawer{sess.screen}))
+	sess.arb = share.NewArbiter(p.Master(), share.ModeShared)
+	s.sessions[name] = sess
+
+	go func() {
+		sess.wait()
+
+		s.mu.Lock()
+		if s.sessions[name] == sess {
+			delete(s.sessions, name)
+		}
+		s.mu.Unlock()
+		close(sess.done)
+	}()
+	return sess, nil
+}
+
+// Session returns the session name, nil if it does not exist.
+func (s *Server) Session(name string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[name]
+}
+
+// Sessions returns the sorted names of the sessions.
+func (s *Server) Sessions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.sessions))
+	for name := range s.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes all the sessions, see Session.Close.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	var err error
+	for _, sess := range sessions {
+		if e := sess.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// serveConn serves the client conn, until it detaches or the session ends.
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }() // Best effort.
+
+	f, err := ReadFrame(conn)
+	if err != nil {
+		return
+	}
+	switch f.Type {
+	case TypeList:
+		_ = WriteFrame(conn, Frame{Type: TypeList, Payload: []byte(strings.Join(s.Sessions(), "\n"))}) // Best effort.
+	case TypeAttach:
+		var req AttachRequest
+		if err := json.Unmarshal(f.Payload, &req); err != nil {
+			_ = WriteFrame(conn, Frame{Type: TypeError, Payload: []byte("session: invalid attach request: " + err.Error())}) // Best effort.
+			return
+		}
+		sess, err := s.attach(&req)
+		if err != nil {
+			_ = WriteFrame(conn, Frame{Type: TypeError, Payload: []byte(err.Error())}) // Best effort.
+			return
+		}
+		sess.serve(conn, &req)
+	default:
+		_ = WriteFrame(conn, Frame{Type: TypeError, Payload: []byte(fmt.Sprintf("session: unexpected frame %d", f.Type))}) // Best effort.
+	}
+}
+
+// attach returns the session of req, started if needed.
+func (s *Server) attach(req *AttachRequest) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sess := s.sessions[req.Name]; sess != nil {
+		return sess, nil
+	}
+	if len(req.Command) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, req.Name)
+	}
+	return s.start(req.Name, exec.Command(req.Command[0], req.Command[1:]...), req.Size) //nolint:gosec // Expected command from the client.
+}
+
+// Session is a program running in a pty, hosted by a Server.
+type Session struct {
+	name   string
+	p      *pty.Pty
+	screen *vt.Screen
+	bc     *share.Broadcaster
+	arb    *share.Arbiter
+
+	mu      sync.Mutex
+	clients int // Number of clients attached so far, for their ID.
+
+	done chan struct{} // Closed once the session ended.
+	code int           // Exit code of the program, set once done.
+}
+
+// Name returns the name of s.
+func (s *Session) Name() string {
+	return s.name
+}
+
+// Pid returns the process ID of the program.
+func (s *Session) Pid() int {
+	return s.p.Cmd().Process.Pid
+}
+
+// Resize resizes the pty and the screen redrawn for the clients attaching.
+func (s *Session) Resize(ws *pty.Winsize) error {
+	return s.screen.Setsize(s.p.Master(), ws)
+}
+
+// Done returns a channel closed once the session ended.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// ExitCode returns the exit code of the program once the session ended,
+// 128 plus the signal number if killed by a signal, as shells do.
+func (s *Session) ExitCode() int {
+	<-s.done
+	return s.code
+}
+
+// Close closes the pty, which hangs up the program.
+func (s *Session) Close() error {
+	return s.p.Close()
+}
+
+// wait waits for the program to exit and its output to be read, and sets
+// its exit code.
+func (s *Session) wait() {
+	err := s.p.Wait()
+	_ = s.bc.Wait() // On Linux, the pty fails with EIO once the tty is closed.
+	_ = s.p.Close() // Best effort.
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		s.code = exitErr.ExitCode()
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			s.code = 128 + int(ws.Signal())
+		}
+	} else if err != nil {
+		s.code = -1
+	}
+}
+
+// serve attaches the client conn, and serves it until it detaches or the
+// session ends.
+func (s *Session) serve(conn net.Conn, req *AttachRequest) {
+	s.mu.Lock()
+	s.clients++
+	id := strconv.Itoa(s.clients)
+	s.mu.Unlock()
+
+	if req.ReadOnly {
+		s.arb.SetViewer(id, true)
+	}
+	defer s.arb.Leave(id)
+	if req.Size != nil && !req.ReadOnly {
+		_ = s.Resize(req.Size) // Best effort.
+	}
+
+	sub := s.bc.Subscribe()
+	defer func() { _ = sub.Close() }() // Best effort.
+	if err := WriteFrame(conn, Frame{Type: TypeOK}); err != nil {
+		return
+	}
+
+	// The output is written by its own goroutine, which closes the
+	// connection once the session ended, to stop reading the input.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.writeOutput(conn, sub)
+	}()
+	defer func() { <-done }()
+
+	for {
+		f, err := ReadFrame(conn)
+		if err != nil {
+			_ = sub.Close() // Best effort.
+			return
+		}
+		switch f.Type {
+		case TypeData:
+			_, _ = s.arb.Write(id, f.Payload) // Best effort, the input of viewers is discarded.
+		case TypeResize:
+			if ws, err := f.Winsize(); err == nil && !req.ReadOnly {
+				_ = s.Resize(ws) // Best effort.
+			}
+		case TypeSignal:
+			if sig, err := f.Signal(); err == nil && !req.ReadOnly {
+				_ = s.p.SignalForeground(sig) // Best effort.
+			}
+		case TypeDetach:
+			_ = sub.Close() // Best effort.
+			return
+		}
+	}
+}
+
+// writeOutput writes the output read from sub to conn, then the exit status
+// once the session ended, or an error if the client is too slow.
+func (s *Session) writeOutput(conn net.Conn, sub *share.Subscriber) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := sub.Read(buf)
+		if n > 0 {
+			if err := WriteFrame(conn, Frame{Type: TypeData, Payload: buf[:n]}); err != nil {
+				_ = conn.Close() // Best effort.
+				return
+			}
+		}
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, io.EOF):
+			_ = WriteFrame(conn, ExitFrame(s.ExitCode())) // Best effort.
+		case errors.Is(err, share.ErrSlowSubscriber):
+			_ = WriteFrame(conn, Frame{Type: TypeError, Payload: []byte(err.Error())}) // Best effort.
+		default: // Detached.
+			return
+		}
+		_ = conn.Close() // Best effort.
+		return
+	}
+}
+
+// redrawer redraws the screen of a session for the clients attaching.
+type redrawer struct {
+	*vt.Screen
+}
+
+// Snapshot implements share.Snapshotter.
+func (r redrawer) Snapshot() []byte {
+	return r.Redraw()
+}
@@ -0,0 +1,190 @@
+//go:build !windows
+// +build !windows
+
+package session
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/creack/pty/vt"
+)
+
+// newServer returns a server listening on a socket, and the path of the
+// socket.
+func newServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ptyd.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Unexpected error from Listen: %s.", err)
+	}
+	s := NewServer()
+	go func() { _ = s.Serve(l) }()                    // Best effort.
+	t.Cleanup(func() { _, _ = l.Close(), s.Close() }) // Best effort.
+	return s, path
+}
+
+// waitScreen renders the output of c until the screen shows text.
+func waitScreen(t *testing.T, c io.ReadCloser, s *vt.Screen, text string) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1024)
+		for !strings.Contains(s.String(), text) {
+			n, err := c.Read(buf)
+			if err != nil {
+				return
+			}
+			_, _ = s.Write(buf[:n])
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		_ = c.Close() // Best effort.
+		<-done
+	}
+	if got := s.String(); !strings.Contains(got, text) {
+		t.Fatalf("Unexpected screen: %q does not contain %q.", got, text)
+	}
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	s, path := newServer(t)
+	if _, err := Dial(path, &AttachRequest{Name: "cat"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Unexpected error attaching to a missing session: %v.", err)
+	}
+
+	ws := &pty.Winsize{Rows: 5, Cols: 20}
+	c1, err := Dial(path, &AttachRequest{Name: "cat", Command: []string{"cat"}, Size: ws})
+	if err != nil {
+		t.Fatalf("Unexpected error from Dial: %s.", err)
+	}
+	if _, err := io.WriteString(c1, "hello\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	// The tty echoes the input, then cat writes it back.
+	waitScreen(t, c1, vt.NewScreen(5, 20), "hello\nhello")
+	if err := c1.Detach(); err != nil {
+		t.Fatalf("Unexpected error from Detach: %s.", err)
+	}
+	if names, err := List(path); err != nil || !reflect.DeepEqual(names, []string{"cat"}) {
+		t.Fatalf("Unexpected sessions: %q, %v.", names, err)
+	}
+
+	// A new client gets the screen redrawn, and the following output.
+	c2, err := Dial(path, &AttachRequest{Name: "cat", Size: &pty.Winsize{Rows: 6, Cols: 30}})
+	if err != nil {
+		t.Fatalf("Unexpected error from Dial: %s.", err)
+	}
+	defer func() { _ = c2.Close() }() // Best effort.
+	screen := vt.NewScreen(6, 30)
+	waitScreen(t, c2, screen, "hello\nhello")
+	if got, err := pty.GetsizeFull(s.Session("cat").p.Master()); err != nil || got.Rows != 6 || got.Cols != 30 {
+		t.Errorf("Unexpected size after attach: %+v, %v.", got, err)
+	}
+	if _, err := io.WriteString(c2, "world\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	waitScreen(t, c2, screen, "hello\nhello\nworld\nworld")
+
+	if err := c2.Resize(&pty.Winsize{Rows: 10, Cols: 40}); err != nil {
+		t.Fatalf("Unexpected error from Resize: %s.", err)
+	}
+
+	// A viewer gets the output, but its input and sizes are discarded.
+	v, err := Dial(path, &AttachRequest{Name: "cat", Size: &pty.Winsize{Rows: 3, Cols: 10}, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Unexpected error from Dial: %s.", err)
+	}
+	defer func() { _ = v.Close() }() // Best effort.
+	if _, err := io.WriteString(v, "ignored\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	if err := v.Signal(syscall.SIGINT); err != nil {
+		t.Fatalf("Unexpected error from Signal: %s.", err)
+	}
+	if err := v.Resize(&pty.Winsize{Rows: 4, Cols: 12}); err != nil {
+		t.Fatalf("Unexpected error from Resize: %s.", err)
+	}
+	if _, err := io.WriteString(c2, "!\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	waitScreen(t, v, vt.NewScreen(10, 40), "world\nworld\n!\n!")
+	if got, err := pty.GetsizeFull(s.Session("cat").p.Master()); err != nil || got.Rows != 10 || got.Cols != 40 {
+		t.Errorf("Unexpected size after resize: %+v, %v.", got, err)
+	}
+
+	// The clients get the exit status once the program is killed.
+	if err := c2.Signal(syscall.SIGINT); err != nil {
+		t.Fatalf("Unexpected error from Signal: %s.", err)
+	}
+	for _, c := range []*Client{c2, v} {
+		if _, err := io.Copy(io.Discard, c); err != nil {
+			t.Fatalf("Unexpected error reading the end of the output: %s.", err)
+		}
+		if code, ok := c.ExitCode(); !ok || code != 128+int(syscall.SIGINT) {
+			t.Errorf("Unexpected exit code: %d, %t.", code, ok)
+		}
+	}
+	if names, err := List(path); err != nil || len(names) != 0 {
+		t.Errorf("Unexpected sessions after exit: %q, %v.", names, err)
+	}
+}
+
+// waitSession waits until the screen of sess shows text.
+func waitSession(t *testing.T, sess *Session, text string) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(sess.screen.String(), text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Unexpected screen: %q does not contain %q.", sess.screen.String(), text)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionLargeRedraw(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(WithBufferSize(64))
+	t.Cleanup(func() { _ = s.Close() }) // Best effort.
+	sess, err := s.Start("cat", exec.Command("cat"), &pty.Winsize{Rows: 5, Cols: 40})
+	if err != nil {
+		t.Fatalf("Unexpected error from Start: %s.", err)
+	}
+	line := strings.Repeat("x", 38)
+	if _, err := io.WriteString(sess.p.Master(), line+"\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	waitSession(t, sess, line+"\n"+line)
+
+	// The redraw is larger than the buffer, the following output must not
+	// disconnect the client attaching.
+	sub := sess.bc.Subscribe()
+	if _, err := io.WriteString(sess.p.Master(), "a\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	waitSession(t, sess, "a\na")
+	// Once read by the session, the previous output was sent to sub.
+	if _, err := io.WriteString(sess.p.Master(), "b\n"); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	waitScreen(t, sub, vt.NewScreen(5, 40), "a\na\nb\nb")
+}
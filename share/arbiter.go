@@ -0,0 +1,295 @@
+package share
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotAllowed is returned by Arbiter.Write when the client may not write.
+var ErrNotAllowed = errors.New("share: client not allowed to write")
+
+// ErrNotOwner is returned by Arbiter.Handoff when the client handing off is
+// not the owner.
+var ErrNotOwner = errors.New("share: client is not the owner")
+
+// EscapeTimeout is the time an incomplete escape sequence written by a
+// client is held back, waiting for its end, before being written as is,
+// e.g. for the Escape key.
+const EscapeTimeout = 50 * time.Millisecond
+
+// maxPending is the maximum size of an incomplete sequence held back, e.g.
+// a device control string.
+const maxPending = 4096
+
+// Mode tells which clients of an Arbiter may write.
+type Mode int
+
+// Modes of an Arbiter. The viewers may never write, see SetViewer.
+const (
+	// ModeSingleOwner lets only the owner write.
+	ModeSingleOwner Mode = iota
+
+	// ModeShared lets all the clients write.
+	ModeShared
+
+	// ModeReadOnly lets no client write.
+	ModeReadOnly
+)
+
+// Arbiter arbitrates the input written by many clients, identified by an
+// ID, to a writer, usually a pty master.
+//
+// The writes are serialized, and an escape sequence or UTF-8 character
+// split across writes of a client is held back until complete, so that the
+// input of a client is never interleaved within a sequence of another.
+type Arbiter struct {
+	w   io.Writer
+	wmu sync.Mutex // Serializes the writes to w.
+
+	mu      sync.Mutex
+	mode    Mode
+	owner   string
+	viewers map[string]bool
+	clients map[string]*client
+}
+
+// client holds the incomplete sequence written by a client.
+type client struct {
+	pending []byte
+	gen     int // Incremented when pending changes, to expire the flush timer.
+	timer   *time.Timer
+}
+
+// NewArbiter returns an Arbiter writing to w in mode. The owner is empty.
+func NewArbiter(w io.Writer, mode Mode) *Arbiter {
+	return &Arbiter{
+		w:       w,
+		mode:    mode,
+		viewers: map[string]bool{},
+		clients: map[string]*client{},
+	}
+}
+
+// Mode returns the mode of a.
+func (a *Arbiter) Mode() Mode {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.mode
+}
+
+// SetMode sets the mode of a.
+func (a *Arbiter) SetMode(mode Mode) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mode = mode
+}
+
+// Owner returns the ID of the owner, empty if none.
+func (a *Arbiter) Owner() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.owner
+}
+
+// SetOwner makes id the owner, e.g. the first client to attach. The input
+// held back for the previous owner is written first.
+func (a *Arbiter) SetOwner(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.setOwner(id)
+}
+
+// Handoff hands the ownership over from the owner from to the client to.
+// It fails with ErrNotOwner if from is not the owner, and ErrNotAllowed if
+// to is a viewer.
+func (a *Arbiter) Handoff(from, to string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if from != a.owner {
+		return ErrNotOwner
+	}
+	if a.viewers[to] {
+		return ErrNotAllowed
+	}
+	a.setOwner(to)
+	return nil
+}
+
+func (a *Arbiter) setOwner(id string) {
+	if c := a.clients[a.owner]; c != nil && a.owner != id {
+		a.flush(c)
+	}
+	a.owner = id
+}
+
+// SetViewer makes id a read-only viewer, or not.
+func (a *Arbiter) SetViewer(id string, viewer bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if viewer {
+		a.viewers[id] = true
+		a.drop(id)
+	} else {
+		delete(a.viewers, id)
+	}
+}
+
+// Leave forgets the client id: its input held back is dropped, and it is
+// no longer the owner or a viewer.
+func (a *Arbiter) Leave(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.drop(id)
+	delete(a.viewers, id)
+	if a.owner == id {
+		a.owner = ""
+	}
+}
+
+// Writer returns a writer for the client id, see Write.
+func (a *Arbiter) Writer(id string) io.Writer {
+	return clientWriter{a: a, id: id}
+}
+
+type clientWriter struct {
+	a  *Arbiter
+	id string
+}
+
+func (w clientWriter) Write(b []byte) (int, error) {
+	return w.a.Write(w.id, b)
+}
+
+// Write writes b as input of the client id, or fails with ErrNotAllowed if
+// the client may not write. An incomplete sequence ending b is held back,
+// to be written with the next write of the client, or after EscapeTimeout.
+func (a *Arbiter) Write(id string, b []byte) (int, error) {
+	a.mu.Lock()
+	if !a.allowed(id) {
+		a.mu.Unlock()
+		return 0, ErrNotAllowed
+	}
+
+	c := a.clients[id]
+	if c == nil {
+		c = &client{}
+		a.clients[id] = c
+	}
+	data := append(c.pending, b...)
+	n := completeInput(data)
+	c.pending = append([]byte(nil), data[n:]...)
+	c.gen++
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if len(c.pending) > 0 {
+		gen := c.gen
+		c.timer = time.AfterFunc(EscapeTimeout, func() { a.expire(id, gen) })
+	}
+
+	// Lock the writes before releasing the state, to write in order.
+	a.wmu.Lock()
+	a.mu.Unlock()
+	defer a.wmu.Unlock()
+
+	if n > 0 {
+		if _, err := a.w.Write(data[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// allowed tells whether the client id may write.
+func (a *Arbiter) allowed(id string) bool {
+	switch {
+	case a.viewers[id]:
+		return false
+	case a.mode == ModeShared:
+		return true
+	case a.mode == ModeSingleOwner:
+		return id == a.owner
+	}
+	return false
+}
+
+// expire writes the input held back for the client id, unless it changed
+// since the generation gen.
+func (a *Arbiter) expire(id string, gen int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if c := a.clients[id]; c != nil && c.gen == gen && a.allowed(id) {
+		a.flush(c)
+	}
+}
+
+// flush writes the input held back for c.
+func (a *Arbiter) flush(c *client) {
+	if len(c.pending) == 0 {
+		return
+	}
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+
+	_, _ = a.w.Write(c.pending) // Best effort.
+	c.pending = nil
+	c.gen++
+}
+
+// drop drops the input held back for the client id.
+func (a *Arbiter) drop(id string) {
+	if c := a.clients[id]; c != nil {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		delete(a.clients, id)
+	}
+}
+
+// completeInput returns the length of b without the incomplete escape
+// sequence or UTF-8 character ending it, if any.
+func completeInput(b []byte) int {
+	if i := bytes.LastIndexByte(b, 0x1b); i >= 0 && len(b)-i <= maxPending && !escapeComplete(b[i:]) {
+		return i
+	}
+	for i := len(b) - 1; i >= 0 && i > len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
+	}
+	return len(b)
+}
+
+// escapeComplete tells whether the escape sequence starting s is complete.
+func escapeComplete(s []byte) bool {
+	if len(s) < 2 {
+		return false
+	}
+	switch s[1] {
+	case '[': // CSI: parameters and intermediates, then the final byte.
+		for _, c := range s[2:] {
+			if c < 0x20 || c > 0x3f {
+				return true
+			}
+		}
+		return false
+	case 'O': // SS3, e.g. a function key.
+		return len(s) > 2
+	case ']': // OSC, terminated by BEL or ST, which starts with ESC.
+		return bytes.IndexByte(s, 0x07) >= 0
+	case 'P', '_', '^', 'X': // DCS, APC, PM and SOS, terminated by ST.
+		return false
+	}
+	return true
+}
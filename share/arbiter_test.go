@@ -0,0 +1,149 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chunkWriter records each write.
+type chunkWriter struct {
+	mu     sync.Mutex
+	chunks []string
+}
+
+func (w *chunkWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.chunks = append(w.chunks, string(b))
+	return len(b), nil
+}
+
+func (w *chunkWriter) Chunks() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.chunks...)
+}
+
+func TestArbiterModes(t *testing.T) {
+	t.Parallel()
+
+	var w chunkWriter
+	a := NewArbiter(&w, ModeSingleOwner)
+	a.SetOwner("alice")
+	a.SetViewer("carol", true)
+
+	write := func(id, s string, want error) {
+		t.Helper()
+		if _, err := a.Write(id, []byte(s)); !errors.Is(err, want) {
+			t.Errorf("Unexpected error from Write by %s in mode %d: %v.", id, a.Mode(), err)
+		}
+	}
+	write("alice", "1", nil)
+	write("bob", "x", ErrNotAllowed)
+	write("carol", "x", ErrNotAllowed)
+
+	a.SetMode(ModeShared)
+	write("alice", "2", nil)
+	write("bob", "3", nil)
+	write("carol", "x", ErrNotAllowed)
+
+	a.SetMode(ModeReadOnly)
+	write("alice", "x", ErrNotAllowed)
+
+	a.SetMode(ModeSingleOwner)
+	if err := a.Handoff("bob", "bob"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("Unexpected error from Handoff by a non owner: %v.", err)
+	}
+	if err := a.Handoff("alice", "carol"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Unexpected error from Handoff to a viewer: %v.", err)
+	}
+	if err := a.Handoff("alice", "bob"); err != nil {
+		t.Fatalf("Unexpected error from Handoff: %s.", err)
+	}
+	write("alice", "x", ErrNotAllowed)
+	if _, err := fmt.Fprint(a.Writer("bob"), "4"); err != nil {
+		t.Errorf("Unexpected error from Write by the new owner: %s.", err)
+	}
+
+	a.Leave("bob")
+	if owner := a.Owner(); owner != "" {
+		t.Errorf("Unexpected owner after Leave: %q.", owner)
+	}
+	if got := w.Chunks(); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("Unexpected input: %q.", got)
+	}
+}
+
+func TestArbiterSequences(t *testing.T) {
+	t.Parallel()
+
+	var w chunkWriter
+	a := NewArbiter(&w, ModeShared)
+	for _, in := range []struct{ id, s string }{
+		{"alice", "a\x1b["},
+		{"bob", "b\x1b"},
+		{"alice", "1;5"},
+		{"bob", "OP\xe2\x82"},
+		{"alice", "A\x1b]0;x"},
+		{"bob", "\xac"},
+		{"alice", "\a"},
+	} {
+		if _, err := a.Write(in.id, []byte(in.s)); err != nil {
+			t.Fatalf("Unexpected error from Write: %s.", err)
+		}
+	}
+	want := []string{"a", "b", "\x1bOP", "\x1b[1;5A", "€", "\x1b]0;x\a"}
+	if got := w.Chunks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected input: %q != %q.", got, want)
+	}
+}
+
+func TestArbiterEscapeTimeout(t *testing.T) {
+	t.Parallel()
+
+	var w chunkWriter
+	a := NewArbiter(&w, ModeShared)
+	if _, err := a.Write("alice", []byte(":wq\x1b")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+
+	// The Escape key is written once no sequence follows it.
+	deadline := time.Now().Add(10 * time.Second)
+	for len(w.Chunks()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(EscapeTimeout)
+	}
+	if got := w.Chunks(); !reflect.DeepEqual(got, []string{":wq", "\x1b"}) {
+		t.Errorf("Unexpected input: %q.", got)
+	}
+}
+
+func TestArbiterConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var w chunkWriter
+	a := NewArbiter(&w, ModeShared)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				// Arrow up, split in the middle of the sequence.
+				_, _ = a.Write(id, []byte("\x1b[")) // Best effort.
+				_, _ = a.Write(id, []byte("A"))     // Best effort.
+			}
+		}(fmt.Sprint(i))
+	}
+	wg.Wait()
+
+	input := strings.Join(w.Chunks(), "")
+	if n := strings.Count(input, "\x1b[A"); n != 800 || len(input) != 800*3 {
+		t.Errorf("Unexpected interleaved input: %d sequences in %d bytes.", n, len(input))
+	}
+}
@@ -0,0 +1,302 @@
+// Package share shares a pty between many clients: a Broadcaster
+// distributes the output of the pty master to all of them, and an Arbiter
+// arbitrates their input.
+package share
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultBufferSize is the default size of the buffer of a subscriber.
+const DefaultBufferSize = 64 * 1024
+
+// ErrSlowSubscriber is returned by Subscriber.Read once the subscriber is
+// disconnected by the PolicyDisconnect policy.
+var ErrSlowSubscriber = errors.New("share: slow subscriber disconnected")
+
+// ErrClosed is returned by Subscriber.Read once the subscriber is closed.
+var ErrClosed = errors.New("share: subscriber closed")
+
+// Policy tells what the Broadcaster does when the output does not fit the
+// buffer of a subscriber which does not read fast enough.
+type Policy int
+
+// Policies for slow subscribers.
+const (
+	// PolicyDrop drops the output, see Subscriber.Dropped.
+	PolicyDrop Policy = iota
+
+	// PolicyDisconnect disconnects the subscriber, whose Read returns
+	// ErrSlowSubscriber after the buffered output.
+	PolicyDisconnect
+
+	// PolicyBlock stops reading the source until the subscriber catches up,
+	// which stalls all the subscribers and the program.
+	PolicyBlock
+)
+
+// Option configures a Broadcaster.
+type Option func(*Broadcaster)
+
+// WithBufferSize sets the size of the buffer of each subscriber.
+func WithBufferSize(n int) Option {
+	return func(b *Broadcaster) { b.bufSize = n }
+}
+
+// WithPolicy sets the policy for slow subscribers. The default is
+// PolicyDrop.
+func WithPolicy(p Policy) Option {
+	return func(b *Broadcaster) { b.policy = p }
+}
+
+// WithReplay replays the last n bytes of output to the new subscribers,
+// within the size of their buffer, so they can catch up, e.g. with the
+// current prompt. The replayed output does not count in the buffer, which
+// only holds the following output.
+func WithReplay(n int) Option {
+	return func(b *Broadcaster) { b.replay = n }
+}
+
+// Snapshotter keeps track of the output of a Broadcaster, e.g. the screen of
+// a terminal, see WithSnapshot.
+type Snapshotter interface {
+	io.Writer
+
+	// Snapshot returns the output reproducing the current state, e.g. the
+	// escape sequences redrawing the screen.
+	Snapshot() []byte
+}
+
+// WithSnapshot writes the output to s, and replays the snapshot of s to the
+// new subscribers instead of the last output, see WithReplay. The snapshot
+// is replayed whole, regardless of the size of the buffer, which only holds
+// the following output.
+func WithSnapshot(s Snapshotter) Option {
+	return func(b *Broadcaster) { b.snap = s }
+}
+
+// Broadcaster reads a source, usually a pty master, and distributes its
+// output to its subscribers.
+type Broadcaster struct {
+	r       io.Reader
+	bufSize int
+	policy  Policy
+	replay  int
+	snap    Snapshotter
+
+	mu      sync.Mutex
+	subs    map[*Subscriber]struct{}
+	history []byte // Last output, up to replay bytes.
+	err     error  // Read error, the source ended when set.
+	done    chan struct{}
+}
+
+// NewBroadcaster starts reading r and returns the Broadcaster distributing
+// its output. The reading stops at the first error.
+func NewBroadcaster(r io.Reader, opts ...Option) *Broadcaster {
+	b := &Broadcaster{
+		r:       r,
+		bufSize: DefaultBufferSize,
+		subs:    map[*Subscriber]struct{}{},
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.bufSize <= 0 {
+		b.bufSize = DefaultBufferSize
+	}
+	go b.readLoop()
+	return b
+}
+
+// Subscribe returns a new subscriber, which receives the output read from
+// now on, preceded by the replayed output, see WithReplay and WithSnapshot.
+func (b *Broadcaster) Subscribe() *Subscriber {
+	s := &Subscriber{b: b}
+	s.cond = sync.NewCond(&s.mu)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay := b.history
+	if len(replay) > b.bufSize {
+		replay = replay[len(replay)-b.bufSize:]
+	}
+	if b.snap != nil {
+		replay = b.snap.Snapshot()
+	}
+	s.buf = append([]byte(nil), replay...)
+	s.replay = len(s.buf)
+	if b.err != nil {
+		s.err = io.EOF
+	} else {
+		b.subs[s] = struct{}{}
+	}
+	return s
+}
+
+// Wait waits for the source to end, and returns the error which ended it,
+// nil for io.EOF.
+func (b *Broadcaster) Wait() error {
+	<-b.done
+	if errors.Is(b.err, io.EOF) {
+		return nil
+	}
+	return b.err
+}
+
+func (b *Broadcaster) readLoop() {
+	defer close(b.done)
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := b.r.Read(buf)
+
+		b.mu.Lock()
+		data := buf[:n]
+		if b.snap != nil && n > 0 {
+			// Under the lock, so a new subscriber receives either the data or
+			// a snapshot including it.
+			_, _ = b.snap.Write(data) // Best effort.
+		}
+		if b.replay > 0 {
+			b.history = append(b.history, data...)
+			if over := len(b.history) - b.replay; over > 0 {
+				b.history = append(b.history[:0], b.history[over:]...)
+			}
+		}
+		subs := make([]*Subscriber, 0, len(b.subs))
+		for s := range b.subs {
+			subs = append(subs, s)
+		}
+		b.mu.Unlock()
+
+		// The subscribers are served out of the lock, as they may block.
+		for _, s := range subs {
+			if !s.send(data, b.policy, b.bufSize) {
+				b.unsubscribe(s)
+			}
+		}
+
+		if err != nil {
+			b.mu.Lock()
+			// On Linux, reading the pty fails with EIO once the tty is closed.
+			b.err = err
+			for s := range b.subs {
+				s.end(io.EOF)
+				delete(b.subs, s)
+			}
+			b.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (b *Broadcaster) unsubscribe(s *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, s)
+}
+
+// Subscriber receives the output of a Broadcaster.
+type Subscriber struct {
+	b *Broadcaster
+
+	mu      sync.Mutex
+	cond    *sync.Cond // Signaled when buf or err change.
+	buf     []byte     // Output not read yet.
+	replay  int        // Length of the replayed output at the start of buf.
+	err     error      // Returned by Read once buf is empty.
+	dropped int64
+}
+
+// Read reads the output of the source. It returns io.EOF once the source
+// ended.
+func (s *Subscriber) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.buf) == 0 && s.err == nil {
+		s.cond.Wait()
+	}
+	if len(s.buf) == 0 {
+		return 0, s.err
+	}
+	n := copy(p, s.buf)
+	s.buf = append(s.buf[:0], s.buf[n:]...)
+	if s.replay -= n; s.replay < 0 {
+		s.replay = 0
+	}
+	s.cond.Broadcast()
+	return n, nil
+}
+
+// Dropped returns the number of bytes of output dropped by the PolicyDrop
+// policy.
+func (s *Subscriber) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close unsubscribes s. The pending and following Read return ErrClosed.
+func (s *Subscriber) Close() error {
+	s.mu.Lock()
+	s.buf, s.replay = nil, 0
+	s.err = ErrClosed
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.b.unsubscribe(s)
+	return nil
+}
+
+// send appends data to the buffer of s, according to policy. The replayed
+// output is not counted in the size of the buffer. It returns false if s
+// must be unsubscribed.
+func (s *Subscriber) send(data []byte, policy Policy, size int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.cond.Broadcast()
+
+	switch {
+	case s.err != nil:
+		return false
+	case len(s.buf)-s.replay+len(data) <= size:
+		s.buf = append(s.buf, data...)
+	case policy == PolicyDrop:
+		s.dropped += int64(len(data))
+	case policy == PolicyDisconnect:
+		s.err = ErrSlowSubscriber
+		return false
+	case policy == PolicyBlock:
+		// Data larger than the buffer is sent in chunks.
+		for len(data) > 0 && s.err == nil {
+			n := size - (len(s.buf) - s.replay)
+			if n <= 0 {
+				s.cond.Wait()
+				continue
+			}
+			if n > len(data) {
+				n = len(data)
+			}
+			s.buf = append(s.buf, data[:n]...)
+			data = data[n:]
+			s.cond.Broadcast()
+		}
+	}
+	return s.err == nil
+}
+
+// end ends s with err, once the buffered output is read.
+func (s *Subscriber) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.cond.Broadcast()
+}
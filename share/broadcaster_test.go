@@ -0,0 +1,230 @@
+//go:build !windows
+// +build !windows
+
+package share
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// readString reads n bytes from r, failing the test after a timeout.
+func readString(t *testing.T, r io.Reader, n int) string {
+	t.Helper()
+
+	type result struct {
+		b   []byte
+		err error
+	}
+	c := make(chan result, 1)
+	go func() {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		c <- result{b, err}
+	}()
+	select {
+	case res := <-c:
+		if res.err != nil {
+			t.Fatalf("Unexpected error reading %d bytes: %s.", n, res.err)
+		}
+		return string(res.b)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timeout reading %d bytes.", n)
+	}
+	return ""
+}
+
+// write writes s to w, failing the test on error.
+func write(t *testing.T, w io.Writer, s string) {
+	t.Helper()
+
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("cat")
+	p, err := pty.Start(c)
+	if err != nil {
+		t.Fatalf("Unexpected error from Start: %s.", err)
+	}
+	defer func() { _ = p.Close() }() // Best effort.
+
+	b := NewBroadcaster(p, WithReplay(4))
+	s1, s2 := b.Subscribe(), b.Subscribe()
+	write(t, p, "hello\n")
+	// The tty echoes the input, then cat writes it back.
+	for _, s := range []*Subscriber{s1, s2} {
+		if got := readString(t, s, 14); got != "hello\r\nhello\r\n" {
+			t.Errorf("Unexpected output: %q.", got)
+		}
+	}
+
+	// Late subscribers get the last 4 bytes first.
+	s3 := b.Subscribe()
+	if got := readString(t, s3, 4); got != "lo\r\n" {
+		t.Errorf("Unexpected replay: %q.", got)
+	}
+
+	// Closed subscribers no longer receive the output.
+	if err := s2.Close(); err != nil {
+		t.Fatalf("Unexpected error from Close: %s.", err)
+	}
+	if _, err := s2.Read(make([]byte, 1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Unexpected error from Read after Close: %v.", err)
+	}
+
+	// The subscribers get io.EOF once the source ended.
+	write(t, p, "\x04") // EOF.
+	if err := c.Wait(); err != nil {
+		t.Fatalf("Unexpected error from Wait: %s.", err)
+	}
+	for _, s := range []*Subscriber{s1, s3} {
+		if out, err := io.ReadAll(s); err != nil || strings.Trim(string(out), "^D\b") != "" {
+			t.Errorf("Unexpected end of output: %q, %v.", out, err)
+		}
+	}
+	_ = b.Wait() // On Linux, the source ends with EIO.
+
+	// Subscribing after the end only gets the replay.
+	if out, err := io.ReadAll(b.Subscribe()); err != nil || len(out) > 4 {
+		t.Errorf("Unexpected output after the end: %q, %v.", out, err)
+	}
+}
+
+func TestBroadcasterPolicies(t *testing.T) {
+	t.Parallel()
+
+	chunks := []string{"abcd", "efg", "hijk"}
+	for _, tc := range []struct {
+		policy Policy
+		output string
+		err    error
+	}{
+		{PolicyDrop, "abcd", io.EOF},
+		{PolicyDisconnect, "abcd", ErrSlowSubscriber},
+		{PolicyBlock, "abcdefghijk", io.EOF},
+	} {
+		r, w := io.Pipe()
+		b := NewBroadcaster(r, WithBufferSize(4), WithPolicy(tc.policy))
+		slow, fast := b.Subscribe(), b.Subscribe()
+
+		if tc.policy == PolicyBlock {
+			// The output is not read from the source until the slow
+			// subscriber reads it.
+			go func() {
+				for _, c := range chunks {
+					_, _ = io.WriteString(w, c) // Best effort.
+				}
+				_ = w.Close() // Best effort.
+			}()
+			go func() { _, _ = io.Copy(io.Discard, fast) }() // Best effort.
+		} else {
+			// The fast subscriber gets the output while the slow one is not
+			// read.
+			for _, c := range chunks {
+				write(t, w, c)
+				if got := readString(t, fast, len(c)); got != c {
+					t.Errorf("Unexpected fast output with policy %d: %q != %q.", tc.policy, got, c)
+				}
+			}
+			_ = w.Close() // Best effort.
+		}
+
+		var out []byte
+		buf := make([]byte, 3)
+		var err error
+		for err == nil {
+			var n int
+			n, err = slow.Read(buf)
+			out = append(out, buf[:n]...)
+		}
+		if string(out) != tc.output || !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected slow output with policy %d: %q, %v.", tc.policy, out, err)
+		}
+		if tc.policy == PolicyDrop && slow.Dropped() != 7 {
+			t.Errorf("Unexpected dropped bytes: %d.", slow.Dropped())
+		}
+		if err := b.Wait(); err != nil {
+			t.Errorf("Unexpected error from Wait: %s.", err)
+		}
+	}
+}
+
+// lenSnapshotter tracks the length of the output.
+type lenSnapshotter int
+
+func (s *lenSnapshotter) Write(b []byte) (int, error) {
+	*s += lenSnapshotter(len(b))
+	return len(b), nil
+}
+
+func (s *lenSnapshotter) Snapshot() []byte {
+	return []byte(fmt.Sprintf("[%d]", *s))
+}
+
+func TestBroadcasterSnapshot(t *testing.T) {
+	t.Parallel()
+
+	r, w := io.Pipe()
+	defer func() { _ = w.Close() }() // Best effort.
+
+	var snap lenSnapshotter
+	b := NewBroadcaster(r, WithSnapshot(&snap), WithReplay(4))
+	s1 := b.Subscribe()
+	if got := readString(t, s1, 3); got != "[0]" {
+		t.Errorf("Unexpected snapshot: %q.", got)
+	}
+	write(t, w, "hello")
+	if got := readString(t, s1, 5); got != "hello" {
+		t.Errorf("Unexpected output: %q.", got)
+	}
+
+	// The snapshot replaces the replay, and is followed by the next output.
+	s2 := b.Subscribe()
+	write(t, w, "!")
+	if got := readString(t, s2, 4); got != "[5]!" {
+		t.Errorf("Unexpected snapshot and output: %q.", got)
+	}
+}
+
+// fixedSnapshotter always returns its snapshot.
+type fixedSnapshotter []byte
+
+func (s fixedSnapshotter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (s fixedSnapshotter) Snapshot() []byte {
+	return s
+}
+
+func TestBroadcasterLargeSnapshot(t *testing.T) {
+	t.Parallel()
+
+	r, w := io.Pipe()
+	defer func() { _ = w.Close() }() // Best effort.
+
+	// The snapshot does not count in the buffer, only the following output.
+	snap := strings.Repeat("s", 100)
+	b := NewBroadcaster(r, WithBufferSize(64), WithPolicy(PolicyDisconnect), WithSnapshot(fixedSnapshotter(snap)))
+	s := b.Subscribe()
+	out := strings.Repeat("o", 63)
+	write(t, w, out)
+	// Once read by the Broadcaster, the previous output was sent.
+	write(t, w, "!")
+	out += "!"
+	if got := readString(t, s, len(snap)+len(out)); got != snap+out {
+		t.Errorf("Unexpected snapshot and output: %q.", got)
+	}
+}
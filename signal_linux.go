@@ -0,0 +1,15 @@
+//go:build linux
+// +build linux
+
+package pty
+
+import (
+	"os"
+	"syscall"
+)
+
+// tiocsig asks the kernel to send sig to the foreground process group of
+// the tty of the pty t. Only supported on pty masters.
+func tiocsig(t *os.File, sig syscall.Signal) error {
+	return ioctl(t, syscall.TIOCSIG, uintptr(sig))
+}
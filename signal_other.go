@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package pty
+
+import (
+	"os"
+	"syscall"
+)
+
+func tiocsig(*os.File, syscall.Signal) error {
+	return ErrUnsupported
+}
@@ -16,10 +16,37 @@ import (
 // This will resize the pty to the specified size before starting the command.
 // Starts the process in a new session and sets the controlling terminal.
 func StartWithSize(cmd *exec.Cmd, ws *Winsize) (*os.File, error) {
+	return StartWithAttrs(cmd, ws, sessionAttrs(cmd))
+}
+
+// StartWithMode is like StartWithSize, but also applies mode to the tty
+// before starting the command, so the child inherits the terminal mode.
+//
+// Typical values for mode are MakeRaw and MakeCbreak.
+func StartWithMode(cmd *exec.Cmd, ws *Winsize, mode func(*os.File) (*Termios, error)) (*os.File, error) {
+	return startWithAttrs(cmd, ws, mode, sessionAttrs(cmd))
+}
+
+// StartPty is like StartWithSize, but returns a Pty keeping track of
+// the command and the tty name.
+//
+// On Linux, the pty is opened in non-blocking mode, see OpenOptions.
+func StartPty(cmd *exec.Cmd, ws *Winsize) (*Pty, error) {
+	return startSession(cmd, ws, nil)
+}
+
+// startSession starts cmd in a new session with the tty as controlling terminal.
+func startSession(cmd *exec.Cmd, ws *Winsize, mode func(*os.File) (*Termios, error)) (*Pty, error) {
+	return startPty(cmd, OpenOptions{NonBlocking: ptyNonBlocking}, ws, mode, sessionAttrs(cmd))
+}
+
+// sessionAttrs sets the attributes of cmd to start it in a new session with
+// the tty as controlling terminal, and returns them.
+func sessionAttrs(cmd *exec.Cmd) *syscall.SysProcAttr {
 	if cmd.SysProcAttr == nil {
 		cmd.SysProcAttr = &syscall.SysProcAttr{}
 	}
 	cmd.SysProcAttr.Setsid = true
 	cmd.SysProcAttr.Setctty = true
-	return StartWithAttrs(cmd, ws, cmd.SysProcAttr)
+	return cmd.SysProcAttr
 }
@@ -0,0 +1,102 @@
+//go:build go1.7
+// +build go1.7
+
+package pty
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// KillStep is a step of the sequence used to terminate a command started
+// with StartContext once its context is done.
+type KillStep struct {
+	Signal syscall.Signal // Signal sent to the session.
+	Grace  time.Duration  // Time given to the command to exit before the next step.
+}
+
+// StartOption configures StartContext.
+type StartOption func(*startOptions)
+
+type startOptions struct {
+	size *Winsize
+	mode func(*os.File) (*Termios, error)
+	kill []KillStep
+}
+
+// WithSize resizes the pty to ws before starting the command.
+func WithSize(ws *Winsize) StartOption {
+	return func(o *startOptions) { o.size = ws }
+}
+
+// WithMode applies mode to the tty before starting the command, see StartWithMode.
+func WithMode(mode func(*os.File) (*Termios, error)) StartOption {
+	return func(o *startOptions) { o.mode = mode }
+}
+
+// WithKillSequence sets the signals sent to the session when the context is done.
+//
+// Defaults to SIGHUP, then SIGTERM after one second, then SIGKILL after another second.
+func WithKillSequence(steps ...KillStep) StartOption {
+	return func(o *startOptions) { o.kill = steps }
+}
+
+// StartContext is like StartPty, but ties the lifetime of the command to ctx.
+//
+// The command is waited for in the background, Wait returns its status.
+// Once ctx is done, the signals of the kill sequence are sent in turn to the
+// process group of the command and to the foreground process group of the
+// terminal, until it exited. The pty is then closed so blocked reads return.
+//
+// The context is no longer watched once the command exited.
+func StartContext(ctx context.Context, cmd *exec.Cmd, opts ...StartOption) (*Pty, error) {
+	o := startOptions{
+		kill: []KillStep{
+			{Signal: syscall.SIGHUP, Grace: time.Second},
+			{Signal: syscall.SIGTERM, Grace: time.Second},
+			{Signal: syscall.SIGKILL},
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p, err := startSession(cmd, o.size, o.mode)
+	if err != nil {
+		return nil, err
+	}
+	go p.watchContext(ctx, o.kill)
+	return p, nil
+}
+
+// watchContext waits for the command, and runs the kill sequence once ctx is
+// done, unless the command exited.
+func (p *Pty) watchContext(ctx context.Context, steps []KillStep) {
+	go func() { _ = p.Wait() }() // The error is returned by Wait to the caller.
+
+	select {
+	case <-p.exited:
+		return
+	case <-ctx.Done():
+	}
+	defer func() { _ = p.master.Close() }() // Best effort.
+
+	for _, step := range steps {
+		if err := p.signalSession(step.Signal); err != nil {
+			return
+		}
+		timer := time.NewTimer(step.Grace)
+		select {
+		case <-p.exited:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//go:build go1.7 && !windows
+// +build go1.7,!windows
+
+package pty
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitSignal waits for the command of p and returns the signal which terminated it.
+func waitSignal(t *testing.T, p *Pty) syscall.Signal {
+	t.Helper()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Wait() }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Command was not terminated.")
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Unexpected error from Wait: %v.", err)
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		t.Fatalf("Expected command to be terminated by a signal: %v.", err)
+	}
+	return status.Signal()
+}
+
+func TestStartContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, err := StartContext(ctx, exec.Command("sleep", "10"))
+	noError(t, err, "Unexpected error from StartContext")
+
+	cancel()
+	assert(t, syscall.SIGHUP, waitSignal(t, p), "Unexpected signal")
+}
+
+func TestStartContextKillSequence(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := exec.Command("sh", "-c", `trap "" HUP TERM; echo ready; sleep 10`)
+	p, err := StartContext(ctx, c, WithKillSequence(
+		KillStep{Signal: syscall.SIGHUP, Grace: 50 * time.Millisecond},
+		KillStep{Signal: syscall.SIGTERM, Grace: 50 * time.Millisecond},
+		KillStep{Signal: syscall.SIGKILL},
+	))
+	noError(t, err, "Unexpected error from StartContext")
+
+	// Make sure the traps are set before canceling.
+	expect := []byte("ready\r\n")
+	buffer := readN(t, p, len(expect), "Unexpected error from Pty Read")
+	assertBytes(t, expect, buffer, "Unexpected result returned from Pty Read")
+
+	cancel()
+	assert(t, syscall.SIGKILL, waitSignal(t, p), "Unexpected signal")
+}
+
+func TestStartContextNoWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, err := StartContext(ctx, exec.Command("sleep", "10"))
+	noError(t, err, "Unexpected error from StartContext")
+
+	// The pty is closed once the command exited, without waiting for it.
+	cancel()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := p.Master().Write(nil); errors.Is(err, os.ErrClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Pty was not closed.")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert(t, syscall.SIGHUP, waitSignal(t, p), "Unexpected signal")
+}
+
+func TestStartContextDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := StartContext(ctx, exec.Command("true")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Unexpected error from StartContext with done context: %v.", err)
+	}
+}
@@ -17,3 +17,19 @@ import (
 func StartWithSize(cmd *exec.Cmd, ws *Winsize) (*os.File, error) {
 	return nil, ErrUnsupported
 }
+
+// StartWithMode is like StartWithSize, but also applies mode to the tty
+// before starting the command, so the child inherits the terminal mode.
+func StartWithMode(cmd *exec.Cmd, ws *Winsize, mode func(*os.File) (*Termios, error)) (*os.File, error) {
+	return nil, ErrUnsupported
+}
+
+// StartPty is like StartWithSize, but returns a Pty keeping track of
+// the command and the tty name.
+func StartPty(cmd *exec.Cmd, ws *Winsize) (*Pty, error) {
+	return nil, ErrUnsupported
+}
+
+func startSession(*exec.Cmd, *Winsize, func(*os.File) (*Termios, error)) (*Pty, error) {
+	return nil, ErrUnsupported
+}
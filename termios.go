@@ -0,0 +1,8 @@
+package pty
+
+// Optional actions for SetAttr, see tcsetattr(3).
+const (
+	TCSANOW   = iota // Change occurs immediately.
+	TCSADRAIN        // Change occurs after all output written to the terminal has been transmitted.
+	TCSAFLUSH        // Like TCSADRAIN, but also discards all input received but not read.
+)
@@ -0,0 +1,11 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package pty
+
+const (
+	ioctlGetAttr      = ioctl_TIOCGETA
+	ioctlSetAttr      = ioctl_TIOCSETA
+	ioctlSetAttrDrain = ioctl_TIOCSETAW
+	ioctlSetAttrFlush = ioctl_TIOCSETAF
+)
@@ -0,0 +1,11 @@
+//go:build linux
+// +build linux
+
+package pty
+
+const (
+	ioctlGetAttr      = ioctl_TCGETS
+	ioctlSetAttr      = ioctl_TCSETS
+	ioctlSetAttrDrain = ioctl_TCSETSW
+	ioctlSetAttrFlush = ioctl_TCSETSF
+)
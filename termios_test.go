@@ -0,0 +1,130 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd || solaris
+// +build linux darwin freebsd dragonfly netbsd openbsd solaris
+
+package pty
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestGetAttr(t *testing.T) {
+	t.Parallel()
+
+	pty, tty := openClose(t)
+
+	pattr, err := GetAttr(pty)
+	noError(t, err, "Unexpected error from pty GetAttr")
+
+	tattr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+
+	assert(t, pattr.Lflag, tattr.Lflag, "Lflag from GetAttr on pty and tty should match")
+	assert(t, pattr.Oflag, tattr.Oflag, "Oflag from GetAttr on pty and tty should match")
+	assert(t, true, tattr.Lflag&ECHO != 0, "Expected ECHO to be enabled by default")
+}
+
+func TestSetAttr(t *testing.T) {
+	t.Parallel()
+
+	pty, tty := openClose(t)
+
+	attr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+
+	attr.Lflag &^= ECHO
+	attr.Oflag &^= OPOST
+	noError(t, SetAttr(tty, TCSANOW, attr), "Unexpected error from SetAttr")
+
+	tattr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+	assert(t, attr.Lflag, tattr.Lflag, "Unexpected GetAttr Lflag result after SetAttr")
+	assert(t, attr.Oflag, tattr.Oflag, "Unexpected GetAttr Oflag result after SetAttr")
+
+	// Without OPOST, LF is not translated to CRLF.
+	text := []byte("pong\n")
+	_, err = tty.Write(text)
+	noError(t, err, "Unexpected error from tty Write")
+
+	buffer := readN(t, pty, len(text), "Unexpected error from pty Read")
+	assertBytes(t, text, buffer, "Unexpected result returned from pty Read")
+}
+
+func TestSetAttrInvalidAction(t *testing.T) {
+	t.Parallel()
+
+	_, tty := openClose(t)
+
+	attr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+
+	if err := SetAttr(tty, -1, attr); err == nil {
+		t.Error("Expected error from SetAttr with invalid action.")
+	}
+}
+
+func TestMakeRawRestore(t *testing.T) {
+	t.Parallel()
+
+	pty, tty := openClose(t)
+
+	oldState, err := MakeRaw(tty)
+	noError(t, err, "Unexpected error from MakeRaw")
+
+	attr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+	assert(t, true, attr.Lflag&(ECHO|ICANON|ISIG) == 0, "Expected ECHO, ICANON and ISIG to be disabled in raw mode")
+	assert(t, true, attr.Oflag&OPOST == 0, "Expected OPOST to be disabled in raw mode")
+
+	// In raw mode, input is available without a LF and is not echoed back.
+	_, err = pty.Write([]byte("ping"))
+	noError(t, err, "Unexpected error from pty Write")
+
+	buffer := readN(t, tty, 4, "Unexpected error from tty Read")
+	assertBytes(t, []byte("ping"), buffer, "Unexpected result returned from tty Read")
+
+	noError(t, Restore(tty, oldState), "Unexpected error from Restore")
+
+	attr, err = GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+	assert(t, oldState.Lflag, attr.Lflag, "Unexpected Lflag after Restore")
+	assert(t, oldState.Oflag, attr.Oflag, "Unexpected Oflag after Restore")
+	assert(t, oldState.Iflag, attr.Iflag, "Unexpected Iflag after Restore")
+}
+
+func TestMakeCbreak(t *testing.T) {
+	t.Parallel()
+
+	_, tty := openClose(t)
+
+	oldState, err := MakeCbreak(tty)
+	noError(t, err, "Unexpected error from MakeCbreak")
+	assert(t, true, oldState.Lflag&ECHO != 0, "Expected ECHO to be enabled in previous state")
+
+	attr, err := GetAttr(tty)
+	noError(t, err, "Unexpected error from tty GetAttr")
+	assert(t, true, attr.Lflag&(ECHO|ICANON) == 0, "Expected ECHO and ICANON to be disabled in cbreak mode")
+	assert(t, true, attr.Lflag&ISIG != 0, "Expected ISIG to be kept in cbreak mode")
+	assert(t, true, attr.Oflag&OPOST != 0, "Expected OPOST to be kept in cbreak mode")
+}
+
+func TestStartWithMode(t *testing.T) {
+	t.Parallel()
+
+	c := exec.Command("cat")
+	pty, err := StartWithMode(c, nil, MakeRaw)
+	noError(t, err, "Unexpected error from StartWithMode")
+	t.Cleanup(func() {
+		_ = pty.Close()      // Best effort.
+		_ = c.Process.Kill() // Best effort.
+		_ = c.Wait()         // Best effort.
+	})
+
+	// The child inherited raw mode: no echo and no CRLF translation.
+	text := []byte("pong\n")
+	_, err = pty.Write(text)
+	noError(t, err, "Unexpected error from pty Write")
+
+	buffer := readN(t, pty, len(text), "Unexpected error from pty Read")
+	assertBytes(t, text, buffer, "Unexpected result returned from pty Read")
+}
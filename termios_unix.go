@@ -0,0 +1,90 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd || solaris
+// +build linux darwin freebsd dragonfly netbsd openbsd solaris
+
+package pty
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// GetAttr returns the terminal attributes of t.
+//
+// When t is a pty, the attributes of its corresponding tty are returned.
+func GetAttr(t *os.File) (*Termios, error) {
+	var attr Termios
+
+	//nolint:gosec // Expected unsafe pointer for Syscall call.
+	if err := ioctl(t, ioctlGetAttr, uintptr(unsafe.Pointer(&attr))); err != nil {
+		return nil, err
+	}
+	return &attr, nil
+}
+
+// SetAttr sets the terminal attributes of t.
+//
+// The when parameter is one of TCSANOW, TCSADRAIN or TCSAFLUSH.
+func SetAttr(t *os.File, when int, attr *Termios) error {
+	var cmd uintptr
+	switch when {
+	case TCSANOW:
+		cmd = ioctlSetAttr
+	case TCSADRAIN:
+		cmd = ioctlSetAttrDrain
+	case TCSAFLUSH:
+		cmd = ioctlSetAttrFlush
+	default:
+		return syscall.EINVAL
+	}
+
+	//nolint:gosec // Expected unsafe pointer for Syscall call.
+	return ioctl(t, cmd, uintptr(unsafe.Pointer(attr)))
+}
+
+// MakeRaw puts the terminal t into raw mode, see cfmakeraw(3),
+// and returns its previous state which can be passed to Restore.
+func MakeRaw(t *os.File) (*Termios, error) {
+	oldState, err := GetAttr(t)
+	if err != nil {
+		return nil, err
+	}
+
+	attr := *oldState
+	attr.Iflag &^= IGNBRK | BRKINT | PARMRK | ISTRIP | INLCR | IGNCR | ICRNL | IXON
+	attr.Oflag &^= OPOST
+	attr.Lflag &^= ECHO | ECHONL | ICANON | ISIG | IEXTEN
+	attr.Cflag &^= CSIZE | PARENB
+	attr.Cflag |= CS8
+	attr.Cc[VMIN] = 1
+	attr.Cc[VTIME] = 0
+	if err := SetAttr(t, TCSANOW, &attr); err != nil {
+		return nil, err
+	}
+	return oldState, nil
+}
+
+// MakeCbreak puts the terminal t into cbreak mode, i.e. disables echo
+// and line buffering while still generating signals, and returns its
+// previous state which can be passed to Restore.
+func MakeCbreak(t *os.File) (*Termios, error) {
+	oldState, err := GetAttr(t)
+	if err != nil {
+		return nil, err
+	}
+
+	attr := *oldState
+	attr.Lflag &^= ECHO | ICANON
+	attr.Cc[VMIN] = 1
+	attr.Cc[VTIME] = 0
+	if err := SetAttr(t, TCSANOW, &attr); err != nil {
+		return nil, err
+	}
+	return oldState, nil
+}
+
+// Restore restores the terminal t to a state previously returned
+// by MakeRaw or MakeCbreak.
+func Restore(t *os.File, state *Termios) error {
+	return SetAttr(t, TCSANOW, state)
+}
@@ -0,0 +1,39 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !netbsd && !openbsd && !solaris
+// +build !linux,!darwin,!freebsd,!dragonfly,!netbsd,!openbsd,!solaris
+
+package pty
+
+import (
+	"os"
+)
+
+// Termios is a dummy struct to enable compilation on unsupported platforms.
+type Termios struct {
+	Iflag, Oflag, Cflag, Lflag uint32
+	Cc                         [20]uint8
+}
+
+// GetAttr returns the terminal attributes of t.
+func GetAttr(*os.File) (*Termios, error) {
+	return nil, ErrUnsupported
+}
+
+// SetAttr sets the terminal attributes of t.
+func SetAttr(*os.File, int, *Termios) error {
+	return ErrUnsupported
+}
+
+// MakeRaw puts the terminal t into raw mode.
+func MakeRaw(*os.File) (*Termios, error) {
+	return nil, ErrUnsupported
+}
+
+// MakeCbreak puts the terminal t into cbreak mode.
+func MakeCbreak(*os.File) (*Termios, error) {
+	return nil, ErrUnsupported
+}
+
+// Restore restores the terminal t to a previous state.
+func Restore(*os.File, *Termios) error {
+	return ErrUnsupported
+}
@@ -8,6 +8,8 @@ package pty
 #include <sys/conf.h>
 #include <sys/param.h>
 #include <sys/filio.h>
+#include <termios.h>
+#include <sys/ioctl.h>
 */
 import "C"
 
@@ -16,3 +18,89 @@ const (
 )
 
 type fiodgnameArg C.struct_fiodname_args
+
+type Termios C.struct_termios
+
+const (
+	ioctl_TIOCGETA  = C.TIOCGETA
+	ioctl_TIOCSETA  = C.TIOCSETA
+	ioctl_TIOCSETAW = C.TIOCSETAW
+	ioctl_TIOCSETAF = C.TIOCSETAF
+)
+
+// Input modes (c_iflag).
+const (
+	IGNBRK  = C.IGNBRK
+	BRKINT  = C.BRKINT
+	IGNPAR  = C.IGNPAR
+	PARMRK  = C.PARMRK
+	INPCK   = C.INPCK
+	ISTRIP  = C.ISTRIP
+	INLCR   = C.INLCR
+	IGNCR   = C.IGNCR
+	ICRNL   = C.ICRNL
+	IXON    = C.IXON
+	IXANY   = C.IXANY
+	IXOFF   = C.IXOFF
+	IMAXBEL = C.IMAXBEL
+)
+
+// Output modes (c_oflag).
+const (
+	OPOST  = C.OPOST
+	ONLCR  = C.ONLCR
+	OCRNL  = C.OCRNL
+	ONOCR  = C.ONOCR
+	ONLRET = C.ONLRET
+)
+
+// Control modes (c_cflag).
+const (
+	CSIZE  = C.CSIZE
+	CS5    = C.CS5
+	CS6    = C.CS6
+	CS7    = C.CS7
+	CS8    = C.CS8
+	CSTOPB = C.CSTOPB
+	CREAD  = C.CREAD
+	PARENB = C.PARENB
+	PARODD = C.PARODD
+	HUPCL  = C.HUPCL
+	CLOCAL = C.CLOCAL
+)
+
+// Local modes (c_lflag).
+const (
+	ISIG    = C.ISIG
+	ICANON  = C.ICANON
+	ECHO    = C.ECHO
+	ECHOE   = C.ECHOE
+	ECHOK   = C.ECHOK
+	ECHONL  = C.ECHONL
+	ECHOCTL = C.ECHOCTL
+	ECHOKE  = C.ECHOKE
+	NOFLSH  = C.NOFLSH
+	TOSTOP  = C.TOSTOP
+	IEXTEN  = C.IEXTEN
+	EXTPROC = C.EXTPROC
+)
+
+// Control characters (c_cc) indices.
+const (
+	VINTR    = C.VINTR
+	VQUIT    = C.VQUIT
+	VERASE   = C.VERASE
+	VKILL    = C.VKILL
+	VEOF     = C.VEOF
+	VTIME    = C.VTIME
+	VMIN     = C.VMIN
+	VSTART   = C.VSTART
+	VSTOP    = C.VSTOP
+	VSUSP    = C.VSUSP
+	VEOL     = C.VEOL
+	VEOL2    = C.VEOL2
+	VWERASE  = C.VWERASE
+	VREPRINT = C.VREPRINT
+	VLNEXT   = C.VLNEXT
+	VDISCARD = C.VDISCARD
+)
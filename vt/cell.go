@@ -0,0 +1,73 @@
+package vt
+
+// Color is a foreground or background color: the default color of the
+// terminal, one of the 256 indexed colors or a 24-bit RGB color.
+type Color uint32
+
+// DefaultColor is the default color of the terminal.
+const DefaultColor Color = 0
+
+const (
+	colorIndexed Color = 1 << 24
+	colorRGB     Color = 2 << 24
+	colorKind          = 0xff << 24
+)
+
+// IndexedColor returns the indexed color i, i.e. one of the 16 ANSI colors
+// (0-15), the 6x6x6 color cube (16-231) or the gray ramp (232-255).
+func IndexedColor(i uint8) Color {
+	return colorIndexed | Color(i)
+}
+
+// RGBColor returns the 24-bit color r, g, b.
+func RGBColor(r, g, b uint8) Color {
+	return colorRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// Index returns the index of an indexed color.
+func (c Color) Index() (uint8, bool) {
+	return uint8(c), c&colorKind == colorIndexed
+}
+
+// RGB returns the components of a 24-bit color.
+func (c Color) RGB() (r, g, b uint8, ok bool) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), c&colorKind == colorRGB
+}
+
+// Attr is a set of text attributes.
+type Attr uint16
+
+// Text attributes, as set by SGR.
+const (
+	AttrBold Attr = 1 << iota
+	AttrFaint
+	AttrItalic
+	AttrUnderline
+	AttrBlink
+	AttrReverse
+	AttrHidden
+	AttrStrike
+)
+
+// Style is the appearance of a cell.
+type Style struct {
+	Fg, Bg Color
+	Attr   Attr
+}
+
+// Cell is a character cell of the screen.
+type Cell struct {
+	// Rune is the character of the cell, ' ' when blank and 0 for the
+	// second cell of a wide character.
+	Rune rune
+
+	// Wide tells whether the character spans this cell and the next one.
+	Wide bool
+
+	Style
+}
+
+// blank returns an erased cell, which keeps the background color of style.
+func blank(style Style) Cell {
+	return Cell{Rune: ' ', Style: Style{Bg: style.Bg}}
+}
@@ -0,0 +1,83 @@
+package vt
+
+// Handler receives the events of a Parser.
+//
+// The slices given to the handler, including the ones of the Sequence, are
+// only valid during the call and must be copied to be retained.
+type Handler interface {
+	// Print displays the character r.
+	Print(r rune)
+
+	// Execute runs the C0 control function b, e.g. '\n' or '\b'.
+	Execute(b byte)
+
+	// CSI runs the control sequence s, e.g. "ESC [ 1 ; 2 H".
+	CSI(s Sequence)
+
+	// ESC runs the escape sequence s, e.g. "ESC 7". It has no parameters.
+	ESC(s Sequence)
+
+	// OSC runs the operating system command data, e.g. "0;title" to set
+	// the window title. The command is terminated by ST or BEL.
+	OSC(data []byte)
+
+	// DCS runs the device control string s with the given data.
+	DCS(s Sequence, data []byte)
+
+	// APC runs the application program command data.
+	APC(data []byte)
+}
+
+// Sequence describes an escape, control or device control sequence.
+type Sequence struct {
+	// Private is the private marker of the parameters, one of '<', '=',
+	// '>' or '?', or 0 if none.
+	Private byte
+
+	// Params holds the parameters.
+	Params Params
+
+	// Intermediates holds the intermediate bytes, between 0x20 and 0x2f.
+	Intermediates []byte
+
+	// Final is the final byte, identifying the function.
+	Final byte
+}
+
+// Params holds the numeric parameters of a sequence, separated by ';'.
+// Each parameter is the parameter value followed by its sub-parameters,
+// separated by ':', e.g. "38:2::255:0:0". Empty values are -1.
+type Params [][]int
+
+// Get returns the value of parameter i, or def if missing or empty.
+func (p Params) Get(i, def int) int {
+	if i >= len(p) || p[i][0] < 0 {
+		return def
+	}
+	return p[i][0]
+}
+
+// NopHandler ignores all events. It is meant to be embedded by handlers
+// only interested in some events.
+type NopHandler struct{}
+
+// Print implements Handler.
+func (NopHandler) Print(rune) {}
+
+// Execute implements Handler.
+func (NopHandler) Execute(byte) {}
+
+// CSI implements Handler.
+func (NopHandler) CSI(Sequence) {}
+
+// ESC implements Handler.
+func (NopHandler) ESC(Sequence) {}
+
+// OSC implements Handler.
+func (NopHandler) OSC([]byte) {}
+
+// DCS implements Handler.
+func (NopHandler) DCS(Sequence, []byte) {}
+
+// APC implements Handler.
+func (NopHandler) APC([]byte) {}
@@ -0,0 +1,372 @@
+// Package vt parses the output of terminal programs, as read from the pty
+// side of a pseudo-terminal, into the escape sequences of VT100 and xterm
+// compatible terminals, renders it on a headless Screen and answers the
+// queries it contains with a Responder.
+package vt
+
+import "unicode/utf8"
+
+// Limits of the parser. Extra intermediates make the sequence ignored,
+// extra parameters and string data are discarded.
+const (
+	maxIntermediates = 2
+	maxParams        = 32
+	maxParamValue    = 0xffff
+	maxDataSize      = 1 << 20
+)
+
+type state uint8
+
+// States of the DEC parser, see https://vt100.net/emu/dec_ansi_parser.
+const (
+	stateGround state = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSIEntry
+	stateCSIParam
+	stateCSIIntermediate
+	stateCSIIgnore
+	stateDCSEntry
+	stateDCSParam
+	stateDCSIntermediate
+	stateDCSPassthrough
+	stateDCSIgnore
+	stateOSCString
+	stateSOSPMString
+	stateAPCString
+)
+
+// Parser implements the state machine of DEC terminals described by Paul
+// Williams, extended with sub-parameters and APC strings, and calls its
+// handler for each recognized event.
+//
+// Text is decoded as UTF-8, even when split across writes. Invalid UTF-8 is
+// printed as utf8.RuneError. As a consequence, 8-bit C1 controls are not
+// supported, their 7-bit equivalents (e.g. "ESC [" for CSI) are.
+type Parser struct {
+	h     Handler
+	state state
+
+	private       byte
+	intermediates [maxIntermediates]byte
+	nIntermediate int
+	ignore        bool // Too many intermediates, the sequence is ignored.
+
+	values    [maxParams]int
+	separator [maxParams]byte // Separator preceding each value.
+	nValue    int
+	value     int  // Current value, -1 if empty.
+	sep       byte // Separator preceding the current value.
+	hasParams bool
+	params    Params // Reused between sequences.
+
+	dcs  Sequence // Hooked device control string.
+	data []byte   // String of OSC, DCS and APC.
+	st   bool     // A string has been terminated by ESC, expect the '\' of ST.
+
+	utf8  [utf8.UTFMax]byte // Incomplete UTF-8 character.
+	nUTF8 int
+	need  int // Length of the incomplete UTF-8 character.
+}
+
+// NewParser returns a parser calling h for each event.
+func NewParser(h Handler) *Parser {
+	p := &Parser{h: h}
+	p.clear()
+	return p
+}
+
+// Write parses b. It never fails, so a Parser can be the destination of
+// io.Copy from a pty.
+func (p *Parser) Write(b []byte) (int, error) {
+	for _, c := range b {
+		p.advance(c)
+	}
+	return len(b), nil
+}
+
+// clear resets the sequence being parsed.
+func (p *Parser) clear() {
+	p.private = 0
+	p.nIntermediate = 0
+	p.ignore = false
+	p.nValue = 0
+	p.value = -1
+	p.sep = ';'
+	p.hasParams = false
+}
+
+func (p *Parser) advance(c byte) {
+	if p.need > 0 {
+		if c&0xc0 == 0x80 {
+			p.utf8[p.nUTF8] = c
+			p.nUTF8++
+			if p.nUTF8 == p.need {
+				r, _ := utf8.DecodeRune(p.utf8[:p.nUTF8])
+				p.need, p.nUTF8 = 0, 0
+				p.h.Print(r)
+			}
+			return
+		}
+		// Not a continuation byte, the character is truncated.
+		p.need, p.nUTF8 = 0, 0
+		p.h.Print(utf8.RuneError)
+	}
+
+	// Transitions from anywhere.
+	switch c {
+	case 0x18, 0x1a: // CAN, SUB: cancel the sequence.
+		p.h.Execute(c)
+		p.state, p.st = stateGround, false
+		return
+	case 0x1b: // ESC
+		p.st = false
+		p.endString()
+		p.clear()
+		p.state = stateEscape
+		return
+	}
+
+	switch p.state {
+	case stateGround:
+		p.ground(c)
+	case stateEscape:
+		p.escape(c)
+	case stateEscapeIntermediate:
+		p.escapeIntermediate(c)
+	case stateCSIEntry, stateCSIParam, stateCSIIntermediate, stateCSIIgnore:
+		p.csi(c)
+	case stateDCSEntry, stateDCSParam, stateDCSIntermediate:
+		p.dcsEntry(c)
+	case stateDCSPassthrough:
+		if c != 0x7f {
+			p.put(c)
+		}
+	case stateOSCString:
+		if c == 0x07 { // BEL terminates OSC, as in xterm.
+			p.h.OSC(p.data)
+			p.state = stateGround
+		} else if c >= 0x20 {
+			p.put(c)
+		}
+	case stateAPCString:
+		if c >= 0x20 {
+			p.put(c)
+		}
+	case stateDCSIgnore, stateSOSPMString:
+	}
+}
+
+func (p *Parser) ground(c byte) {
+	switch {
+	case c < 0x20:
+		p.h.Execute(c)
+	case c < 0x7f:
+		p.h.Print(rune(c))
+	case c == 0x7f:
+	case c >= 0xc2 && c <= 0xdf:
+		p.startUTF8(c, 2)
+	case c >= 0xe0 && c <= 0xef:
+		p.startUTF8(c, 3)
+	case c >= 0xf0 && c <= 0xf4:
+		p.startUTF8(c, 4)
+	default:
+		p.h.Print(utf8.RuneError)
+	}
+}
+
+func (p *Parser) startUTF8(c byte, n int) {
+	p.utf8[0] = c
+	p.nUTF8, p.need = 1, n
+}
+
+func (p *Parser) escape(c byte) {
+	st := p.st
+	p.st = false
+
+	switch {
+	case c < 0x20:
+		p.h.Execute(c)
+		p.st = st
+	case c < 0x30:
+		p.collect(c)
+		p.state = stateEscapeIntermediate
+	case c == '[':
+		p.state = stateCSIEntry
+	case c == ']':
+		p.startString(stateOSCString)
+	case c == 'P':
+		p.state = stateDCSEntry
+	case c == 'X', c == '^':
+		p.state = stateSOSPMString
+	case c == '_':
+		p.startString(stateAPCString)
+	case c == '\\' && st:
+		// End of the string terminator.
+		p.state = stateGround
+	case c < 0x7f:
+		p.escDispatch(c)
+	}
+}
+
+func (p *Parser) escapeIntermediate(c byte) {
+	switch {
+	case c < 0x20:
+		p.h.Execute(c)
+	case c < 0x30:
+		p.collect(c)
+	case c < 0x7f:
+		p.escDispatch(c)
+	}
+}
+
+func (p *Parser) escDispatch(c byte) {
+	if !p.ignore {
+		p.h.ESC(Sequence{Intermediates: p.intermediates[:p.nIntermediate], Final: c})
+	}
+	p.state = stateGround
+}
+
+func (p *Parser) csi(c byte) {
+	switch {
+	case c < 0x20:
+		p.h.Execute(c)
+	case c >= 0x7f:
+		// DEL is ignored, and so are the bytes of 8-bit C1 controls and
+		// UTF-8 characters, which are not part of the sequence.
+	case p.state == stateCSIIgnore:
+		if c >= 0x40 {
+			p.state = stateGround
+		}
+	case c >= 0x40:
+		if !p.ignore {
+			p.h.CSI(p.sequence(c))
+		}
+		p.state = stateGround
+	default:
+		p.state = p.entry(c, stateCSIParam, stateCSIIntermediate, stateCSIIgnore)
+	}
+}
+
+func (p *Parser) dcsEntry(c byte) {
+	switch {
+	case c < 0x20, c >= 0x7f:
+	case c >= 0x40:
+		// Hook: the sequence is dispatched with its data once terminated.
+		if p.ignore {
+			p.state = stateDCSIgnore
+			return
+		}
+		s := p.sequence(c)
+		s.Params = append(Params(nil), s.Params...)
+		s.Intermediates = append([]byte(nil), s.Intermediates...)
+		p.dcs = s
+		p.startString(stateDCSPassthrough)
+	default:
+		p.state = p.entry(c, stateDCSParam, stateDCSIntermediate, stateDCSIgnore)
+	}
+}
+
+// entry handles the parameter and intermediate bytes of CSI and DCS
+// sequences, between 0x20 and 0x3f, and returns the next state.
+func (p *Parser) entry(c byte, param, intermediate, ignore state) state {
+	entry := p.state == stateCSIEntry || p.state == stateDCSEntry
+	switch {
+	case c < 0x30:
+		p.collect(c)
+		return intermediate
+	case p.state == intermediate:
+		// Parameters after intermediates.
+		return ignore
+	case c <= ';':
+		p.param(c)
+		return param
+	case entry:
+		p.private = c
+		return param
+	}
+	// Private marker after parameters.
+	return ignore
+}
+
+func (p *Parser) collect(c byte) {
+	if p.nIntermediate == maxIntermediates {
+		p.ignore = true
+		return
+	}
+	p.intermediates[p.nIntermediate] = c
+	p.nIntermediate++
+}
+
+func (p *Parser) param(c byte) {
+	p.hasParams = true
+	if c == ';' || c == ':' {
+		p.pushParam()
+		p.sep = c
+		return
+	}
+	if p.value < 0 {
+		p.value = 0
+	}
+	if p.value = p.value*10 + int(c-'0'); p.value > maxParamValue {
+		p.value = maxParamValue
+	}
+}
+
+func (p *Parser) pushParam() {
+	if p.nValue < maxParams {
+		p.values[p.nValue] = p.value
+		p.separator[p.nValue] = p.sep
+		p.nValue++
+	}
+	p.value = -1
+}
+
+// sequence returns the sequence being parsed, ended by final.
+func (p *Parser) sequence(final byte) Sequence {
+	if p.hasParams {
+		p.pushParam()
+	}
+	p.params = p.params[:0]
+	start := 0
+	for i := 0; i < p.nValue; i++ {
+		if i == 0 || p.separator[i] != ':' {
+			start = i
+			p.params = append(p.params, nil)
+		}
+		p.params[len(p.params)-1] = p.values[start : i+1 : i+1]
+	}
+	return Sequence{
+		Private:       p.private,
+		Params:        p.params,
+		Intermediates: p.intermediates[:p.nIntermediate],
+		Final:         final,
+	}
+}
+
+func (p *Parser) startString(s state) {
+	p.data = p.data[:0]
+	p.state = s
+}
+
+func (p *Parser) put(c byte) {
+	if len(p.data) < maxDataSize {
+		p.data = append(p.data, c)
+	}
+}
+
+// endString dispatches the string being parsed, when terminated by ESC.
+func (p *Parser) endString() {
+	switch p.state {
+	case stateOSCString:
+		p.h.OSC(p.data)
+	case stateDCSPassthrough:
+		p.h.DCS(p.dcs, p.data)
+	case stateAPCString:
+		p.h.APC(p.data)
+	case stateDCSIgnore, stateSOSPMString:
+	default:
+		return
+	}
+	p.st = true
+}
@@ -0,0 +1,136 @@
+package vt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recorder records the events as strings, consecutive prints being merged.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) Print(c rune) {
+	if n := len(r.events) - 1; n >= 0 && strings.HasPrefix(r.events[n], "print ") {
+		r.events[n] += string(c)
+		return
+	}
+	r.events = append(r.events, "print "+string(c))
+}
+
+func (r *recorder) Execute(b byte) {
+	r.events = append(r.events, fmt.Sprintf("execute %#x", b))
+}
+
+func (r *recorder) CSI(s Sequence) {
+	r.events = append(r.events, "csi "+formatSequence(s))
+}
+
+func (r *recorder) ESC(s Sequence) {
+	r.events = append(r.events, "esc "+formatSequence(s))
+}
+
+func (r *recorder) OSC(data []byte) {
+	r.events = append(r.events, fmt.Sprintf("osc %q", data))
+}
+
+func (r *recorder) DCS(s Sequence, data []byte) {
+	r.events = append(r.events, fmt.Sprintf("dcs %s %q", formatSequence(s), data))
+}
+
+func (r *recorder) APC(data []byte) {
+	r.events = append(r.events, fmt.Sprintf("apc %q", data))
+}
+
+func formatSequence(s Sequence) string {
+	var b strings.Builder
+	if s.Private != 0 {
+		b.WriteByte(s.Private)
+	}
+	fmt.Fprint(&b, s.Params)
+	fmt.Fprintf(&b, "%q%c", s.Intermediates, s.Final)
+	return b.String()
+}
+
+func parse(chunks ...string) []string {
+	r := &recorder{}
+	p := NewParser(r)
+	for _, c := range chunks {
+		_, _ = p.Write([]byte(c))
+	}
+	return r.events
+}
+
+func TestParser(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"hello\r\n", []string{"print hello", "execute 0xd", "execute 0xa"}},
+		{"é€😀", []string{"print é€😀"}},
+		{"\xff\xe2\x82a", []string{"print ��a"}},
+		{"\x1b[H", []string{`csi []""H`}},
+		{"\x1b[1;2H", []string{`csi [[1] [2]]""H`}},
+		{"\x1b[;5H", []string{`csi [[-1] [5]]""H`}},
+		{"\x1b[?1049h", []string{`csi ?[[1049]]""h`}},
+		{"\x1b[38:2::255:0:0m", []string{`csi [[38 2 -1 255 0 0]]""m`}},
+		{"\x1b[0 q", []string{`csi [[0]]" "q`}},
+		{"\x1b[99999999A", []string{`csi [[65535]]""A`}},
+		{"\x1b[1?A", nil},
+		{"\x1b[1\nA", []string{"execute 0xa", `csi [[1]]""A`}},
+		{"\x1b[1\x18A", []string{"execute 0x18", "print A"}},
+		{"\x1b[1\x7f\x80é\x9bA", []string{`csi [[1]]""A`}},
+		{"\x1b[1?\xfeAx", []string{"print x"}},
+		{"\x1bP1\xc3$r0m\x1b\\", []string{`dcs [[1]]"$"r "0m"`}},
+		{"\x1b7\x1b(B", []string{`esc []""7`, `esc []"("B`}},
+		{"\x1b]0;title\a", []string{`osc "0;title"`}},
+		{"\x1b]2;été\x1b\\x", []string{`osc "2;été"`, "print x"}},
+		{"\x1bP1$r0m\x1b\\", []string{`dcs [[1]]"$"r "0m"`}},
+		{"\x1b_Gf=24;data\x1b\\", []string{`apc "Gf=24;data"`}},
+		{"\x1bXsos\x1b\\", nil},
+		{"\x1b\\", []string{`esc []""\`}},
+	} {
+		got := parse(tc.in)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("Unexpected events for %q: %q != %q.", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestParserSplit checks the input can be split anywhere, e.g. across reads.
+func TestParserSplit(t *testing.T) {
+	t.Parallel()
+
+	in := "a\x1b[1;2Hé\x1b]0;t\x1b\\😀\x1bP$q\"p\x1b\\\x1b(0"
+	want := fmt.Sprint(parse(in))
+	for i := range in {
+		if got := fmt.Sprint(parse(in[:i], in[i:])); got != want {
+			t.Errorf("Unexpected events when split at %d: %s != %s.", i, got, want)
+		}
+	}
+}
+
+func FuzzParser(f *testing.F) {
+	for _, s := range []string{
+		"hello\r\n",
+		"\x1b[1;2H\x1b[?25l\x1b[38:2::1:2:3m",
+		"\x1b]0;title\a\x1b]8;;url\x1b\\",
+		"\x1bP1$r0m\x1b\\\x1b_apc\x1b\\",
+		"é€😀\xff\xe2\x82",
+	} {
+		f.Add(s, 1)
+	}
+
+	f.Fuzz(func(t *testing.T, in string, split int) {
+		if split < 0 || split > len(in) {
+			split = len(in) / 2
+		}
+		want := fmt.Sprint(parse(in))
+		if got := fmt.Sprint(parse(in[:split], in[split:])); got != want {
+			t.Errorf("Unexpected events when split at %d: %s != %s.", split, got, want)
+		}
+	})
+}
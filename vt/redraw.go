@@ -0,0 +1,185 @@
+package vt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Redraw returns the output drawing the screen on a terminal of the same
+// size, e.g. for a client attaching to a running program: the main buffer
+// and, when displayed, the alternate buffer, with the cursor and the cursors
+// saved by DECSC, the scroll region, the tab stops, the modes and the title.
+// The scrollback is not drawn.
+func (s *Screen) Redraw() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.t
+	r := redrawer{t: t}
+	r.WriteString("\x1b[?1049l\x1b(B\x1b[0m\x1b[r\x1b[?6l\x1b[?7h\x1b[4l\x1b[H\x1b[2J")
+	r.buffer(&t.main)
+	if t.buf == &t.alt {
+		// Entering the alternate buffer saves the cursor of the main one.
+		r.cursor(&t.main.saved)
+		r.WriteString("\x1b[?1049h\x1b(B\x1b[0m")
+		r.style = Style{}
+		r.buffer(&t.alt)
+	}
+	r.cursor(&t.buf.saved)
+	r.WriteString("\x1b7\x1b(B")
+	r.tabs()
+
+	if t.top != 0 || t.bottom != t.rows-1 {
+		r.WriteString("\x1b[" + strconv.Itoa(t.top+1) + ";" + strconv.Itoa(t.bottom+1) + "r")
+	}
+	row := t.cur.row
+	if t.cur.origin {
+		r.WriteString("\x1b[?6h")
+		row -= t.top
+	}
+	if t.cur.wrapNext {
+		// Print the last cell again, so the next character wraps.
+		col := t.cols - 1
+		if cells := t.buf.lines[t.cur.row].cells; cells[col].Rune == 0 && col > 0 {
+			col--
+		}
+		r.moveTo(row, col)
+		r.cell(t.buf.lines[t.cur.row].cells[col])
+	} else {
+		r.moveTo(row, t.cur.col)
+	}
+	r.setStyle(t.cur.style)
+	if t.cur.graphics {
+		r.WriteString("\x1b(0")
+	}
+
+	if !t.autowrap {
+		r.WriteString("\x1b[?7l")
+	}
+	if t.insert {
+		r.WriteString("\x1b[4h")
+	}
+	if t.cursorHidden {
+		r.WriteString("\x1b[?25l")
+	} else {
+		r.WriteString("\x1b[?25h")
+	}
+	if t.title != "" {
+		r.WriteString("\x1b]2;" + t.title + "\a")
+	}
+	return []byte(r.String())
+}
+
+// redrawer writes the output redrawing a terminal.
+type redrawer struct {
+	strings.Builder
+	t     *terminal
+	style Style // Current style of the output.
+}
+
+// buffer draws the lines of b, from the top left corner of a cleared
+// screen, without scroll region and with autowrap.
+func (r *redrawer) buffer(b *buffer) {
+	for row, l := range b.lines {
+		cells := l.cells
+		if !l.wrapped {
+			// The screen is cleared with the default style. A cell is kept
+			// after a wrapped line, to wrap it.
+			keep := 0
+			if row > 0 && b.lines[row-1].wrapped {
+				keep = 1
+			}
+			for len(cells) > keep && cells[len(cells)-1] == blank(Style{}) {
+				cells = cells[:len(cells)-1]
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		if row > 0 && !b.lines[row-1].wrapped {
+			r.moveTo(row, 0)
+		}
+		for i, c := range cells {
+			if c.Rune == 0 && i > 0 && cells[i-1].Wide {
+				continue
+			}
+			r.cell(c)
+		}
+	}
+}
+
+// cell prints c.
+func (r *redrawer) cell(c Cell) {
+	r.setStyle(c.Style)
+	if c.Rune == 0 {
+		c.Rune = ' '
+	}
+	r.WriteRune(c.Rune)
+}
+
+// cursor moves the cursor to c, with its style and character set, to be
+// saved.
+func (r *redrawer) cursor(c *cursor) {
+	r.moveTo(c.row, c.col)
+	r.setStyle(c.style)
+	if c.graphics {
+		r.WriteString("\x1b(0")
+	}
+}
+
+// tabs sets the tab stops, when they are not the default ones.
+func (r *redrawer) tabs() {
+	custom := false
+	for i, tab := range r.t.tabs {
+		custom = custom || tab != (i%8 == 0)
+	}
+	if !custom {
+		return
+	}
+	r.WriteString("\x1b[3g")
+	for i, tab := range r.t.tabs {
+		if tab {
+			r.moveTo(0, i)
+			r.WriteString("\x1bH")
+		}
+	}
+}
+
+// moveTo moves the cursor to the 0-based row, col.
+func (r *redrawer) moveTo(row, col int) {
+	r.WriteString("\x1b[" + strconv.Itoa(row+1) + ";" + strconv.Itoa(col+1) + "H")
+}
+
+// setStyle sets the style of the output to style, if not already set.
+func (r *redrawer) setStyle(style Style) {
+	if style == r.style {
+		return
+	}
+	r.style = style
+	r.WriteString("\x1b[0")
+	for i, a := range []Attr{AttrBold, AttrFaint, AttrItalic, AttrUnderline, AttrBlink, 0, AttrReverse, AttrHidden, AttrStrike} {
+		if a != 0 && style.Attr&a != 0 {
+			r.WriteString(";" + strconv.Itoa(i+1))
+		}
+	}
+	r.color(style.Fg, 30)
+	r.color(style.Bg, 40)
+	r.WriteString("m")
+}
+
+// color writes the SGR parameters of the foreground color c if base is 30,
+// or of the background color if base is 40.
+func (r *redrawer) color(c Color, base int) {
+	if i, ok := c.Index(); ok {
+		switch {
+		case i < 8:
+			r.WriteString(";" + strconv.Itoa(base+int(i)))
+		case i < 16:
+			r.WriteString(";" + strconv.Itoa(base+60+int(i)-8))
+		default:
+			r.WriteString(";" + strconv.Itoa(base+8) + ";5;" + strconv.Itoa(int(i)))
+		}
+	} else if red, green, blue, ok := c.RGB(); ok {
+		r.WriteString(";" + strconv.Itoa(base+8) + ";2;" + strconv.Itoa(int(red)) + ";" + strconv.Itoa(int(green)) + ";" + strconv.Itoa(int(blue)))
+	}
+}
@@ -0,0 +1,91 @@
+package vt
+
+import (
+	"reflect"
+	"testing"
+)
+
+// assertRedraw asserts that the redraw of s draws the same screen.
+func assertRedraw(t *testing.T, s *Screen) {
+	t.Helper()
+
+	rows, cols := s.Size()
+	got := NewScreen(rows, cols)
+	_, _ = got.Write(s.Redraw())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	want, have := s.t, got.t
+	alt := want.buf == &want.alt
+	if alt != (have.buf == &have.alt) {
+		t.Fatalf("Unexpected alt screen after redraw: %t.", !alt)
+	}
+	buffers := [][2]*buffer{{&want.main, &have.main}}
+	if alt {
+		// The alternate buffer is cleared when displayed again.
+		buffers = append(buffers, [2]*buffer{&want.alt, &have.alt})
+	}
+	for _, b := range buffers {
+		for row := range b[0].lines {
+			w, h := b[0].lines[row], b[1].lines[row]
+			if row == rows-1 {
+				// The last line can only be wrapped below a scroll region.
+				h.wrapped = w.wrapped
+			}
+			if !reflect.DeepEqual(h, w) {
+				t.Errorf("Unexpected line %d after redraw: %+v != %+v.", row, h, w)
+			}
+		}
+		// The origin mode and the pending wrap of the saved cursors are not
+		// redrawn.
+		w, h := b[0].saved, b[1].saved
+		w.origin, w.wrapNext = false, false
+		if h != w {
+			t.Errorf("Unexpected saved cursor after redraw: %+v != %+v.", h, w)
+		}
+	}
+	for _, f := range []struct {
+		name       string
+		have, want interface{}
+	}{
+		{"cursor", have.cur, want.cur},
+		{"scroll region", [2]int{have.top, have.bottom}, [2]int{want.top, want.bottom}},
+		{"modes", [3]bool{have.autowrap, have.insert, have.cursorHidden}, [3]bool{want.autowrap, want.insert, want.cursorHidden}},
+		{"tabs", have.tabs, want.tabs},
+		{"title", have.title, want.title},
+	} {
+		if !reflect.DeepEqual(f.have, f.want) {
+			t.Errorf("Unexpected %s after redraw: %+v != %+v.", f.name, f.have, f.want)
+		}
+	}
+}
+
+func TestRedraw(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name, output string
+	}{
+		{"empty", ""},
+		{"text", "hello\r\nworld"},
+		{"style", "\x1b[1;4;31;104ma\x1b[22;24;38:2::1:2:3;48;5;200mb\x1b[0;7;93mc\x1b[42m\x1b[K"},
+		{"wrap", "abcdefgh\r\nx"},
+		{"wrap next", "\x1b[2;1Habcdef"},
+		{"wide wrap next", "\x1b[2;1Habcd世"},
+		{"wide", "a世界xy\r\n\x1b[32m世"},
+		{"scroll region", "1\r\n2\x1b[2;3r\x1b[?6h\x1b[2;2H\x1b[33mx"},
+		{"modes", "\x1b]0;title\a\x1b[?25l\x1b[?7l\x1b[4h"},
+		{"tabs", "\x1b[3g\x1b[1;3H\x1bH\x1b[1;5H\x1bH\x1b[1;1H"},
+		{"saved cursor", "\x1b[2;3H\x1b[31m\x1b(0\x1b7\x1b(B\x1b[0m\x1b[H"},
+		{"graphics", "\x1b(0lqk"},
+		{"alt screen", "main\x1b[2;2H\x1b[1m\x1b[?1049h\x1b[0malt\x1b[3;1H\x1b7\x1b[H"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assertRedraw(t, newScreen(t, 3, 6, tc.output))
+		})
+	}
+}
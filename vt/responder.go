@@ -0,0 +1,203 @@
+package vt
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultVersion is the terminal name and version reported by a Responder
+// to XTVERSION.
+const DefaultVersion = "creack-pty"
+
+// Replies of the Responder.
+const (
+	replyPrimaryDA   = "\x1b[?1;2c"    // VT100 with advanced video option.
+	replySecondaryDA = "\x1b[>0;10;1c" // VT100, firmware version 10, no ROM cartridge.
+	replyStatus      = "\x1b[0n"       // Ready, no malfunction.
+)
+
+// maxPendingReplies is the size of the replies queued for a program which
+// does not read its input, past which the replies are dropped.
+const maxPendingReplies = 64 * 1024
+
+// ResponderOption configures a Responder.
+type ResponderOption func(*Responder)
+
+// WithScreen renders the output read by the Responder on s, and reports the
+// cursor of s. The output must not be written to s otherwise.
+func WithScreen(s *Screen) ResponderOption {
+	return func(r *Responder) { r.screen = s }
+}
+
+// WithCursor sets the 0-based cursor position reported without a Screen.
+// The default is the top left corner.
+func WithCursor(row, col int) ResponderOption {
+	return func(r *Responder) { r.row, r.col = row, col }
+}
+
+// WithVersion sets the terminal name and version reported to XTVERSION.
+func WithVersion(version string) ResponderOption {
+	return func(r *Responder) { r.version = version }
+}
+
+// Responder answers the queries a program sends about the terminal and then
+// waits for, e.g. vim or fish on startup, so they don't hang when no
+// terminal emulator reads the pty. It wraps the pty master f: the output
+// read from it is scanned for queries, and the replies are written back to
+// f as input of the program, by another goroutine so the output is still
+// read while the program does not read its input.
+//
+// The queries answered are the primary and secondary device attributes
+// (DA1, DA2), the device status and cursor position reports (DSR, CPR,
+// DECXCPR) and the terminal version (XTVERSION).
+type Responder struct {
+	f       *os.File
+	screen  *Screen
+	row     int
+	col     int
+	version string
+
+	parser  *Parser
+	replies []byte // Replies to the output being parsed.
+
+	mu      sync.Mutex
+	pending []byte // Replies not written yet.
+	writing bool   // The pending replies are being written.
+	err     error  // Error writing a reply, returned by Read.
+}
+
+// NewResponder returns a Responder wrapping the pty master f.
+func NewResponder(f *os.File, opts ...ResponderOption) *Responder {
+	r := &Responder{f: f, version: DefaultVersion}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var h Handler = NopHandler{}
+	if r.screen != nil {
+		h = r.screen.t
+	}
+	r.parser = NewParser(queryHandler{Handler: h, r: r})
+	return r
+}
+
+// Read reads the output of the program from the pty, and queues the replies
+// to the queries found in it. An error writing a reply is returned by the
+// next Read, after the output read.
+func (r *Responder) Read(b []byte) (int, error) {
+	n, err := r.f.Read(b)
+	if n > 0 {
+		r.respond(b[:n])
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil && r.err != nil {
+		err, r.err = r.err, nil
+	}
+	return n, err
+}
+
+// Write writes b to the pty, as input of the program.
+func (r *Responder) Write(b []byte) (int, error) {
+	return r.f.Write(b)
+}
+
+// respond parses the output b and queues the replies to its queries.
+func (r *Responder) respond(b []byte) {
+	if r.screen != nil {
+		r.screen.mu.Lock()
+		_, _ = r.parser.Write(b)
+		r.screen.mu.Unlock()
+	} else {
+		_, _ = r.parser.Write(b)
+	}
+	if len(r.replies) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending)+len(r.replies) <= maxPendingReplies {
+		r.pending = append(r.pending, r.replies...)
+	}
+	r.replies = r.replies[:0]
+	if !r.writing && len(r.pending) > 0 {
+		r.writing = true
+		go r.writeReplies()
+	}
+}
+
+// writeReplies writes the pending replies, until there are none left.
+func (r *Responder) writeReplies() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(r.pending) > 0 {
+		b := r.pending
+		r.pending = nil
+		r.mu.Unlock()
+		_, err := r.f.Write(b)
+		r.mu.Lock()
+		if err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	r.writing = false
+}
+
+// cursor returns the 1-based position of the cursor, relative to the scroll
+// region in origin mode.
+func (r *Responder) cursor() (row, col int) {
+	if r.screen == nil {
+		return r.row + 1, r.col + 1
+	}
+	t := r.screen.t
+	row = t.cur.row
+	if t.cur.origin {
+		row -= t.top
+	}
+	return row + 1, t.cur.col + 1
+}
+
+// queryHandler passes the events to Handler, and queues the replies to the
+// queries of the Responder.
+type queryHandler struct {
+	Handler
+	r *Responder
+}
+
+// CSI implements Handler.
+func (h queryHandler) CSI(s Sequence) {
+	h.Handler.CSI(s)
+	if len(s.Intermediates) > 0 || len(s.Params) > 1 {
+		return
+	}
+
+	r := h.r
+	switch p := s.Params.Get(0, 0); {
+	case s.Final == 'c' && s.Private == 0 && p == 0: // DA1
+		r.replies = append(r.replies, replyPrimaryDA...)
+	case s.Final == 'c' && s.Private == '>' && p == 0: // DA2
+		r.replies = append(r.replies, replySecondaryDA...)
+	case s.Final == 'n' && s.Private == 0 && p == 5: // DSR
+		r.replies = append(r.replies, replyStatus...)
+	case s.Final == 'n' && (s.Private == 0 || s.Private == '?') && p == 6: // CPR, DECXCPR
+		row, col := r.cursor()
+		r.replies = append(r.replies, "\x1b["...)
+		if s.Private == '?' {
+			r.replies = append(r.replies, '?')
+		}
+		r.replies = strconv.AppendInt(r.replies, int64(row), 10)
+		r.replies = append(r.replies, ';')
+		r.replies = strconv.AppendInt(r.replies, int64(col), 10)
+		if s.Private == '?' {
+			r.replies = append(r.replies, ";1"...) // Page.
+		}
+		r.replies = append(r.replies, 'R')
+	case s.Final == 'q' && s.Private == '>' && p == 0: // XTVERSION
+		r.replies = append(r.replies, "\x1bP>|"...)
+		r.replies = append(r.replies, r.version...)
+		r.replies = append(r.replies, "\x1b\\"...)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build !windows
+// +build !windows
+
+package vt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// openRaw opens a pty with the tty in raw mode, so the replies are read
+// as written.
+func openRaw(t *testing.T) (p, tty *os.File) {
+	t.Helper()
+
+	p, tty, err := pty.Open()
+	if err != nil {
+		t.Fatalf("Unexpected error from Open: %s.", err)
+	}
+	t.Cleanup(func() { _, _ = p.Close(), tty.Close() }) // Best effort.
+	if _, err := pty.MakeRaw(tty); err != nil {
+		t.Fatalf("Unexpected error from MakeRaw: %s.", err)
+	}
+	return p, tty
+}
+
+// assertReply writes the query to the tty, as a program would, and asserts
+// the reply read back.
+func assertReply(t *testing.T, tty *os.File, query, reply string) {
+	t.Helper()
+
+	if _, err := tty.WriteString(query); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	timer := time.AfterFunc(10*time.Second, func() { _ = tty.Close() }) // Best effort.
+	defer timer.Stop()
+
+	buf := make([]byte, len(reply))
+	if _, err := io.ReadFull(tty, buf); err != nil {
+		t.Fatalf("Unexpected error reading the reply to %q: %s.", query, err)
+	}
+	if string(buf) != reply {
+		t.Errorf("Unexpected reply to %q: %q != %q.", query, buf, reply)
+	}
+}
+
+func TestResponder(t *testing.T) {
+	t.Parallel()
+
+	p, tty := openRaw(t)
+	r := NewResponder(p, WithCursor(4, 9), WithVersion("test 1.0"))
+	go func() { _, _ = io.Copy(io.Discard, r) }() // Best effort.
+
+	for _, tc := range []struct {
+		query, reply string
+	}{
+		{"\x1b[c", "\x1b[?1;2c"},
+		{"\x1b[0c", "\x1b[?1;2c"},
+		{"\x1b[>c", "\x1b[>0;10;1c"},
+		{"\x1b[5n", "\x1b[0n"},
+		{"\x1b[6n", "\x1b[5;10R"},
+		{"\x1b[?6n", "\x1b[?5;10;1R"},
+		{"\x1b[>q", "\x1bP>|test 1.0\x1b\\"},
+		// Split sequences and ignored ones.
+		{"\x1b[1;1H\x1b[?2004h\x1b[", ""},
+		{"6n", "\x1b[5;10R"},
+	} {
+		assertReply(t, tty, tc.query, tc.reply)
+	}
+
+	if _, err := r.Write([]byte("input")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertReply(t, tty, "", "input")
+}
+
+func TestResponderScreen(t *testing.T) {
+	t.Parallel()
+
+	p, tty := openRaw(t)
+	s := NewScreen(10, 20)
+	r := NewResponder(p, WithScreen(s))
+	go func() { _, _ = io.Copy(io.Discard, r) }() // Best effort.
+
+	// The position is the one following the output preceding the query.
+	assertReply(t, tty, "hello\r\nworld\x1b[6nagain\x1b[6n", "\x1b[2;6R\x1b[2;11R")
+	assertReply(t, tty, "\x1b[3;8r\x1b[?6h\x1b[2;3H\x1b[6n", "\x1b[2;3R")
+
+	if got := s.Line(1); got != "worldagain" {
+		t.Errorf("Unexpected screen line: %q.", got)
+	}
+}
+
+func TestResponderBlocked(t *testing.T) {
+	t.Parallel()
+
+	p, tty := openRaw(t)
+	r := NewResponder(p)
+
+	// The program does not read the replies, which fill the input of the tty.
+	go func() { _, _ = tty.WriteString(strings.Repeat("\x1b[5n", 1<<15) + "done") }() // Best effort.
+	timer := time.AfterFunc(10*time.Second, func() { _ = tty.Close() })               // Best effort.
+	defer timer.Stop()
+
+	var out []byte
+	buf := make([]byte, 1024)
+	for !bytes.HasSuffix(out, []byte("done")) {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("Unexpected error from Read: %s.", err)
+		}
+		out = append(out, buf[:n]...)
+	}
+}
@@ -0,0 +1,186 @@
+package vt
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/creack/pty"
+)
+
+// Screen is a headless terminal: it renders the output of a program, as read
+// from the pty, on a grid of cells. It supports text attributes and colors,
+// scroll regions, the alternate screen buffer, line wrapping and wide
+// characters. Zero width characters, e.g. combining marks, are discarded.
+//
+// The lines of the main buffer are reflowed on resize. The lines scrolled off
+// its top can be kept in a scrollback, see SetScrollback.
+//
+// A Screen is safe for concurrent use, e.g. fed by io.Copy from the pty
+// while another goroutine inspects it.
+type Screen struct {
+	mu     sync.Mutex
+	t      *terminal
+	parser *Parser
+}
+
+// NewScreen returns a blank screen of the given size.
+func NewScreen(rows, cols int) *Screen {
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+	t := newTerminal(rows, cols)
+	return &Screen{t: t, parser: NewParser(t)}
+}
+
+// Write renders the output b. It never fails.
+func (s *Screen) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.parser.Write(b)
+}
+
+// SetScrollback keeps up to maxLines lines scrolled off the top of the main
+// buffer, and up to maxBytes bytes of text if maxBytes > 0. The oldest lines
+// are dropped first. The scrollback is disabled if maxLines <= 0, which is
+// the default.
+func (s *Screen) SetScrollback(maxLines, maxBytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maxLines <= 0 {
+		s.t.sb = nil
+		return
+	}
+	if s.t.sb == nil {
+		s.t.sb = &scrollback{}
+	}
+	s.t.sb.maxLines, s.t.sb.maxBytes = maxLines, maxBytes
+	s.t.sb.evict()
+}
+
+// ScrollbackLen returns the number of lines in the scrollback.
+func (s *Screen) ScrollbackLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.t.sb == nil {
+		return 0
+	}
+	return len(s.t.sb.lines)
+}
+
+// Resize resizes the screen. The cursor is kept on screen.
+func (s *Screen) Resize(rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.resize(rows, cols)
+}
+
+// Setsize resizes the terminal t and the screen in lockstep, so the output
+// following the resize is rendered with the new size. See pty.Setsize.
+func (s *Screen) Setsize(t *os.File, ws *pty.Winsize) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := pty.Setsize(t, ws); err != nil {
+		return err
+	}
+	s.t.resize(int(ws.Rows), int(ws.Cols))
+	return nil
+}
+
+// Size returns the size of the screen.
+func (s *Screen) Size() (rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.rows, s.t.cols
+}
+
+// Cursor returns the 0-based position of the cursor.
+func (s *Screen) Cursor() (row, col int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.cur.row, s.t.cur.col
+}
+
+// CursorVisible tells whether the cursor is visible, see DECTCEM.
+func (s *Screen) CursorVisible() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.t.cursorHidden
+}
+
+// AltScreen tells whether the alternate screen buffer is displayed.
+func (s *Screen) AltScreen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.buf == &s.t.alt
+}
+
+// Title returns the window title set by the program.
+func (s *Screen) Title() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.title
+}
+
+// Cell returns the cell at row, col, or a zero cell if out of the screen and
+// of the scrollback. The rows of the scrollback are negative, see Line.
+func (s *Screen) Cell(row, col int) Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.t.line(row)
+	switch {
+	case l == nil || col < 0 || col >= s.t.cols:
+		return Cell{}
+	case col >= len(l.cells): // Trimmed scrollback line.
+		return blank(Style{})
+	}
+	return l.cells[col]
+}
+
+// Line returns the text of the line row, without trailing spaces. The
+// lines of the scrollback are numbered from -1, the most recent, to
+// -ScrollbackLen().
+func (s *Screen) Line(row int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.t.line(row)
+	if l == nil {
+		return ""
+	}
+	return lineText(l.cells)
+}
+
+// String returns the visible text, one line per row without trailing
+// spaces. Trailing empty lines are omitted.
+func (s *Screen) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]string, 0, s.t.rows)
+	for _, l := range s.t.buf.lines {
+		lines = append(lines, lineText(l.cells))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineText returns the text of cells, without trailing spaces.
+func lineText(cells []Cell) string {
+	var b strings.Builder
+	for _, c := range cells {
+		if c.Rune != 0 {
+			b.WriteRune(c.Rune)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
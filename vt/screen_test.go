@@ -0,0 +1,168 @@
+package vt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/creack/pty"
+)
+
+func newScreen(t *testing.T, rows, cols int, output string) *Screen {
+	t.Helper()
+
+	s := NewScreen(rows, cols)
+	if _, err := s.Write([]byte(output)); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	return s
+}
+
+func assertScreen(t *testing.T, s *Screen, text string, row, col int) {
+	t.Helper()
+
+	if got := s.String(); got != text {
+		t.Errorf("Unexpected screen: %q != %q.", got, text)
+	}
+	if r, c := s.Cursor(); r != row || c != col {
+		t.Errorf("Unexpected cursor: %d,%d != %d,%d.", r, c, row, col)
+	}
+}
+
+func TestScreen(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		output   string
+		text     string
+		row, col int
+	}{
+		{"text", "hello\r\nworld", "hello\nworld", 1, 5},
+		{"wrap", "abcdefgh", "abcdef\ngh", 1, 2},
+		{"no wrap", "\x1b[?7labcdefgh", "abcdeh", 0, 5},
+		{"scroll", "1\r\n2\r\n3\r\n4\r\n5", "3\n4\n5", 2, 1},
+		{"scroll region", "top\x1b[2;3r\x1b[3;1H2\r\n3\r\n4", "top\n3\n4", 2, 1},
+		{"reverse index", "1\r\n2\x1b[H\x1bM0", "0\n1\n2", 0, 1},
+		{"cursor moves", "\x1b[2;4Hx\x1b[Ay\x1b[2Dz\x1b[Bw", "   zy\n   xw", 1, 5},
+		{"erase", "abcdef\r\nghijkl\x1b[1;3H\x1b[K\x1b[2;3H\x1b[1K", "ab\n   jkl", 1, 2},
+		{"erase display", "abc\r\ndef\r\nghi\x1b[2;2H\x1b[J", "abc\nd", 1, 1},
+		{"insert delete chars", "abcdef\x1b[1;2H\x1b[2@xy\x1b[P", "axycd", 0, 3},
+		{"insert delete lines", "1\r\n2\r\n3\x1b[2H\x1b[L4\x1b[3H\x1b[M", "1\n4", 2, 0},
+		{"tabs", "a\tb\x1b[Zc", "c    b", 0, 1},
+		{"repeat", "ab\x1b[3b", "abbbb", 0, 5},
+		{"wide", "a世界xy", "a世界x\ny", 1, 1},
+		{"wide wrap", "abcde世", "abcde\n世", 1, 2},
+		{"wide overwrite", "a世界\x1b[1;3Hz", "a z界", 0, 3},
+		{"save restore", "ab\x1b7\r\ncd\x1b8e", "abe\ncd", 0, 3},
+		{"alt screen", "ma\x1b[?1049halt", "  alt", 0, 5},
+		{"main screen", "ma\x1b[?1049halt\x1b[?1049lx", "max", 0, 3},
+		{"graphics", "\x1b(0lqk\x1b(Bq", "┌─┐q", 0, 4},
+		{"reset", "abc\x1b[31m\x1bc", "", 0, 0},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assertScreen(t, newScreen(t, 3, 6, tc.output), tc.text, tc.row, tc.col)
+		})
+	}
+}
+
+func TestScreenStyle(t *testing.T) {
+	t.Parallel()
+
+	s := newScreen(t, 2, 10, "\x1b[1;4;31;104ma\x1b[22;24;38:2::1:2:3;48;5;200mb\x1b[0;7mc\x1b[38;2;4;5;6;3md")
+
+	for i, want := range []Style{
+		{Fg: IndexedColor(1), Bg: IndexedColor(12), Attr: AttrBold | AttrUnderline},
+		{Fg: RGBColor(1, 2, 3), Bg: IndexedColor(200)},
+		{Attr: AttrReverse},
+		{Fg: RGBColor(4, 5, 6), Attr: AttrReverse | AttrItalic},
+	} {
+		if got := s.Cell(0, i).Style; got != want {
+			t.Errorf("Unexpected style of cell %d: %+v != %+v.", i, got, want)
+		}
+	}
+
+	// Erased cells keep the background color.
+	s = newScreen(t, 2, 10, "\x1b[42m\x1b[2J")
+	if got := s.Cell(1, 9); got != (Cell{Rune: ' ', Style: Style{Bg: IndexedColor(2)}}) {
+		t.Errorf("Unexpected erased cell: %+v.", got)
+	}
+}
+
+func TestScreenModes(t *testing.T) {
+	t.Parallel()
+
+	s := newScreen(t, 2, 10, "\x1b]0;title\a\x1b[?25l\x1b[?1049h")
+	if s.Title() != "title" || s.CursorVisible() || !s.AltScreen() {
+		t.Errorf("Unexpected modes: title %q, cursor visible %t, alt screen %t.", s.Title(), s.CursorVisible(), s.AltScreen())
+	}
+}
+
+func TestScreenResize(t *testing.T) {
+	t.Parallel()
+
+	s := newScreen(t, 4, 6, "1\r\n2\r\n3 世\r\n")
+	s.Resize(2, 3)
+	assertScreen(t, s, "世", 1, 0)
+
+	s.Resize(3, 8)
+	if _, err := s.Write([]byte("abcdefghij")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertScreen(t, s, "世\nabcdefgh\nij", 2, 2)
+
+	// The alternate buffer is not reflowed.
+	s = newScreen(t, 2, 6, "\x1b[?1049habcdefgh")
+	s.Resize(2, 3)
+	assertScreen(t, s, "abc\ngh", 1, 2)
+}
+
+func TestScreenSetsize(t *testing.T) {
+	t.Parallel()
+
+	p, tty, err := pty.Open()
+	if errors.Is(err, pty.ErrUnsupported) {
+		t.Skip("Pty not supported.")
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error from Open: %s.", err)
+	}
+	defer func() { _, _ = p.Close(), tty.Close() }() // Best effort.
+
+	s := NewScreen(24, 80)
+	if err := s.Setsize(p, &pty.Winsize{Rows: 10, Cols: 20}); err != nil {
+		t.Fatalf("Unexpected error from Setsize: %s.", err)
+	}
+	rows, cols, err := pty.Getsize(tty)
+	if err != nil {
+		t.Fatalf("Unexpected error from Getsize: %s.", err)
+	}
+	if r, c := s.Size(); r != rows || c != cols || r != 10 || c != 20 {
+		t.Errorf("Unexpected sizes: screen %dx%d, tty %dx%d.", r, c, rows, cols)
+	}
+}
+
+func FuzzScreen(f *testing.F) {
+	for _, s := range []string{
+		"hello\r\nworld\x1b[2;3r\x1b[3;1H\n\n",
+		"a世界\x1b[1;3Hz\x1b[2@\x1b[5P\x1b[3b",
+		"\x1b[?1049h\x1b[31;48:2::1:2:3m\x1b[2J\x1b[?1049l",
+		"\x1b[?6h\x1b[5;5r\x1b[99;99H\x1bM\x1b[L\x1b[M\x1b[S\x1b[T",
+	} {
+		f.Add(s, uint8(5), uint8(10))
+	}
+
+	f.Fuzz(func(t *testing.T, output string, rows, cols uint8) {
+		s := NewScreen(4, 8)
+		s.SetScrollback(5, 20)
+		half := len(output) / 2
+		_, _ = s.Write([]byte(output[:half]))
+		s.Resize(int(rows%50), int(cols%50))
+		_, _ = s.Write([]byte(output[half:]))
+		_ = s.String()
+		_ = s.Search("a")
+		assertRedraw(t, s)
+	})
+}
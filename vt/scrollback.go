@@ -0,0 +1,168 @@
+package vt
+
+// scrollback stores the lines scrolled off the top of the main buffer,
+// oldest first, within its limits.
+type scrollback struct {
+	maxLines int
+	maxBytes int // No limit if <= 0.
+
+	lines []line
+	bytes int // Size of lines, see lineSize.
+}
+
+// lineSize returns the size accounted for l: the size of its text, plus
+// a line feed.
+func lineSize(l line) int {
+	return len(lineText(l.cells)) + 1
+}
+
+// push appends lines, then drops the oldest lines exceeding the limits.
+func (sb *scrollback) push(lines ...line) {
+	if sb == nil {
+		return
+	}
+	for _, l := range lines {
+		if !l.wrapped {
+			l.cells = trimCells(l.cells)
+		}
+		sb.lines = append(sb.lines, l)
+		sb.bytes += lineSize(l)
+	}
+	sb.evict()
+}
+
+// evict drops the oldest lines exceeding the limits.
+func (sb *scrollback) evict() {
+	for len(sb.lines) > 0 && (len(sb.lines) > sb.maxLines || sb.maxBytes > 0 && sb.bytes > sb.maxBytes) {
+		sb.bytes -= lineSize(sb.lines[0])
+		sb.lines[0] = line{} // Release the cells.
+		sb.lines = sb.lines[1:]
+	}
+}
+
+func (sb *scrollback) clear() {
+	if sb == nil {
+		return
+	}
+	sb.lines = nil
+	sb.bytes = 0
+}
+
+// trimCells removes the trailing blank cells without style.
+func trimCells(cells []Cell) []Cell {
+	n := len(cells)
+	for n > 0 && cells[n-1] == blank(Style{}) {
+		n--
+	}
+	return cells[:n:n]
+}
+
+// reflow resizes the main buffer to rows and cols, and rewraps its lines and
+// the scrollback when cols changes: the lines wrapped by autowrap are joined
+// and wrapped again, and the cursor follows its character. When there are
+// more lines than rows, the lines below the cursor are removed first, then
+// the top lines are pushed to the scrollback.
+func (t *terminal) reflow(rows, cols int) {
+	cur := &t.main.saved
+	if t.buf == &t.main {
+		cur = &t.cur
+	}
+
+	lines := t.main.lines
+	if cols != t.cols {
+		lines, cur.row, cur.col = rewrapLines(lines, cols, cur.row, cur.col)
+		if t.sb != nil {
+			t.sb.lines, _, _ = rewrapLines(t.sb.lines, cols, -1, 0)
+			t.sb.bytes = 0
+			for i, l := range t.sb.lines {
+				if !l.wrapped {
+					t.sb.lines[i].cells = trimCells(l.cells)
+				}
+				t.sb.bytes += lineSize(l)
+			}
+			t.sb.evict()
+		}
+	}
+
+	if excess := len(lines) - rows; excess > 0 {
+		below := clamp(len(lines)-1-cur.row, 0, excess)
+		lines = lines[:len(lines)-below]
+		t.sb.push(lines[:excess-below]...)
+		lines = lines[excess-below:]
+		cur.row -= excess - below
+	}
+	for len(lines) < rows {
+		lines = append(lines, line{cells: blankCells(cols, Style{})})
+	}
+	t.main.lines = lines
+}
+
+// rewrapLines wraps lines to cols columns. It returns the new lines and the
+// new position of the cell at row, col, if row >= 0.
+func rewrapLines(lines []line, cols, row, col int) (out []line, newRow, newCol int) {
+	var cells []Cell
+	off := -1 // Offset of row, col in cells.
+	for i, l := range lines {
+		if i == row {
+			off = len(cells) + col
+		}
+		if l.wrapped {
+			n := len(l.cells)
+			if i < len(lines)-1 && n > 0 && l.cells[n-1] == blank(Style{}) && lines[i+1].cells[0].Wide {
+				n-- // Left blank, the wide character did not fit.
+			}
+			cells = append(cells, l.cells[:n]...)
+			if i < len(lines)-1 {
+				continue
+			}
+		} else {
+			cells = append(cells, trimCells(l.cells)...)
+		}
+
+		wrapped, r, c := rewrap(cells, cols, off)
+		if off >= 0 {
+			newRow, newCol = len(out)+r, c
+			off = -1
+		}
+		wrapped[len(wrapped)-1].wrapped = l.wrapped
+		out = append(out, wrapped...)
+		cells = cells[:0]
+	}
+	return out, newRow, newCol
+}
+
+// rewrap wraps the cells of a logical line to cols columns, without splitting
+// wide characters. It returns the lines and the position of the cell at
+// offset off, or of the end of the text if off is past it.
+func rewrap(cells []Cell, cols, off int) (lines []line, row, col int) {
+	row = -1
+	l := make([]Cell, 0, cols)
+	for i := 0; i < len(cells); i++ {
+		c, w := cells[i], 1
+		if c.Wide {
+			w = 2
+		}
+		if w > cols {
+			c, w = blank(c.Style), 1
+		}
+		if len(l)+w > cols {
+			lines = append(lines, line{cells: resizeCells(l, cols), wrapped: true})
+			l = make([]Cell, 0, cols)
+		}
+		if off == i || off == i+1 && c.Wide {
+			row, col = len(lines), len(l)+off-i
+		}
+		l = append(l, c)
+		if c.Wide {
+			l = append(l, Cell{Style: c.Style})
+		}
+		if cells[i].Wide {
+			i++ // Skip the second half.
+		}
+	}
+	if row < 0 {
+		row, col = len(lines), clamp(len(l)+off-len(cells), 0, cols-1)
+	}
+	lines = append(lines, line{cells: resizeCells(l, cols)})
+	return lines, row, col
+}
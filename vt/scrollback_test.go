@@ -0,0 +1,142 @@
+package vt
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func assertScrollback(t *testing.T, s *Screen, lines ...string) {
+	t.Helper()
+
+	var got []string
+	for row := -s.ScrollbackLen(); row < 0; row++ {
+		got = append(got, s.Line(row))
+	}
+	if !reflect.DeepEqual(got, lines) {
+		t.Errorf("Unexpected scrollback: %q != %q.", got, lines)
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	t.Parallel()
+
+	s := NewScreen(3, 6)
+	s.SetScrollback(3, 0)
+	if _, err := s.Write([]byte("1\r\n2\r\n3\r\n4\r\n5\x1b[31m\r\n6")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertScrollback(t, s, "1", "2", "3")
+	assertScreen(t, s, "4\n5\n6", 2, 1)
+	if got := s.Cell(-1, 0).Rune; got != '3' {
+		t.Errorf("Unexpected scrollback cell: %q.", got)
+	}
+	if got := s.Cell(-1, 5); got != blank(Style{}) {
+		t.Errorf("Unexpected trimmed scrollback cell: %+v.", got)
+	}
+	if got := s.Cell(-4, 0); got != (Cell{}) {
+		t.Errorf("Unexpected cell out of the scrollback: %+v.", got)
+	}
+
+	// Line limit.
+	if _, err := s.Write([]byte("\r\n7")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertScrollback(t, s, "2", "3", "4")
+
+	// Byte limit, a line feed is counted per line.
+	s.SetScrollback(3, 4)
+	assertScrollback(t, s, "3", "4")
+
+	// Scroll regions and the alternate buffer do not feed the scrollback.
+	if _, err := s.Write([]byte("\x1b[2;3r\x1b[3H\n\n\x1b[r\x1b[?1049h\n\n\n\x1b[?1049l")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertScrollback(t, s, "3", "4")
+
+	// ED 3 clears the scrollback.
+	if _, err := s.Write([]byte("\x1b[3J")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertScrollback(t, s)
+
+	s.SetScrollback(0, 0)
+	if _, err := s.Write([]byte("\n\n\n")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	assertScrollback(t, s)
+}
+
+func TestScrollbackReflow(t *testing.T) {
+	t.Parallel()
+
+	s := NewScreen(3, 8)
+	s.SetScrollback(10, 0)
+	if _, err := s.Write([]byte("abcdefghij\r\n12 世界\r\n$ ")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+
+	// Narrower: the lines are wrapped again, without splitting the wide
+	// characters, and the top lines are pushed to the scrollback.
+	s.Resize(3, 4)
+	assertScrollback(t, s, "abcd", "efgh", "ij")
+	assertScreen(t, s, "12\n世界\n$", 2, 2)
+
+	// Wider: the wrapped lines are joined.
+	s.Resize(3, 12)
+	assertScrollback(t, s, "abcdefghij")
+	assertScreen(t, s, "12 世界\n$", 1, 2)
+
+	// Less rows: the lines below the cursor are removed first.
+	s = NewScreen(4, 4)
+	s.SetScrollback(10, 0)
+	if _, err := s.Write([]byte("abcdef\x1b[H")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	s.Resize(2, 2)
+	assertScrollback(t, s)
+	assertScreen(t, s, "ab\ncd", 0, 0)
+	s.Resize(2, 8)
+	assertScreen(t, s, "abcd", 0, 0)
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	s := NewScreen(2, 6)
+	s.SetScrollback(10, 0)
+	if _, err := s.Write([]byte("foo bar\r\nbaz 世foo\r\nfoo")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	// Scrollback: "foo ba" (wrapped), "r", "baz 世" (wrapped). Screen: "foo", "foo".
+	if n := s.ScrollbackLen(); n != 3 {
+		t.Fatalf("Unexpected scrollback length: %d.", n)
+	}
+
+	for _, tc := range []struct {
+		name string
+		hits []Hit
+		got  []Hit
+	}{
+		{"substring", []Hit{{-3, 0, "foo"}, {0, 0, "foo"}, {1, 0, "foo"}}, s.Search("foo")},
+		{"wrapped", []Hit{{-3, 4, "bar"}}, s.Search("bar")},
+		{"wide", []Hit{{-1, 4, "世foo"}}, s.Search("世foo")},
+		{"none", nil, s.Search("qux")},
+		{"empty", nil, s.Search("")},
+		{"regexp", []Hit{{-3, 4, "bar"}, {-1, 0, "baz"}}, s.SearchRegexp(regexp.MustCompile(`ba.`))},
+		{"anchors", []Hit{{-1, 0, "baz 世foo"}, {1, 0, "foo"}}, s.SearchRegexp(regexp.MustCompile(`^b.*$|^foo$`))},
+		{"empty matches", nil, s.SearchRegexp(regexp.MustCompile(`x*`))},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.hits) {
+			t.Errorf("Unexpected %s hits: %v != %v.", tc.name, tc.got, tc.hits)
+		}
+	}
+
+	// The alternate buffer is searched without the scrollback.
+	if _, err := s.Write([]byte("\x1b[?1049hfoo")); err != nil {
+		t.Fatalf("Unexpected error from Write: %s.", err)
+	}
+	if got := s.Search("foo"); !reflect.DeepEqual(got, []Hit{{1, 3, "foo"}}) {
+		t.Errorf("Unexpected alternate buffer hits: %v.", got)
+	}
+}
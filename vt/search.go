@@ -0,0 +1,89 @@
+package vt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Hit is a match found by Search or SearchRegexp.
+type Hit struct {
+	// Row and Col are the position of the first cell of the match. Row is
+	// negative in the scrollback, see Screen.Line.
+	Row, Col int
+
+	// Text is the matched text.
+	Text string
+}
+
+// Search returns the non-overlapping occurrences of substr in the scrollback
+// and on the screen, from the oldest line. The lines wrapped by autowrap are
+// searched as one line.
+func (s *Screen) Search(substr string) []Hit {
+	if substr == "" {
+		return nil
+	}
+	return s.search(func(text string) [][]int {
+		var matches [][]int
+		for i := 0; ; {
+			j := strings.Index(text[i:], substr)
+			if j < 0 {
+				return matches
+			}
+			i += j
+			matches = append(matches, []int{i, i + len(substr)})
+			i += len(substr)
+		}
+	})
+}
+
+// SearchRegexp is like Search, with the matches of re. Empty matches are
+// ignored.
+func (s *Screen) SearchRegexp(re *regexp.Regexp) []Hit {
+	return s.search(func(text string) [][]int {
+		return re.FindAllStringIndex(text, -1)
+	})
+}
+
+// search returns the matches found by find in the text of each logical line.
+func (s *Screen) search(find func(text string) [][]int) []Hit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := s.t.buf.lines
+	first := 0 // Row of lines[0].
+	if sb := s.t.sb; sb != nil && s.t.buf == &s.t.main {
+		lines = append(append(make([]line, 0, len(sb.lines)+len(lines)), sb.lines...), lines...)
+		first = -len(sb.lines)
+	}
+
+	var (
+		hits []Hit
+		b    strings.Builder
+		pos  [][2]int // Row and column of each byte of b.
+	)
+	for i, l := range lines {
+		for col, c := range l.cells {
+			if c.Rune == 0 {
+				continue
+			}
+			b.WriteRune(c.Rune)
+			for len(pos) < b.Len() {
+				pos = append(pos, [2]int{first + i, col})
+			}
+		}
+		if l.wrapped && i < len(lines)-1 {
+			continue
+		}
+
+		text := strings.TrimRight(b.String(), " ")
+		for _, m := range find(text) {
+			if m[0] == m[1] {
+				continue
+			}
+			hits = append(hits, Hit{Row: pos[m[0]][0], Col: pos[m[0]][1], Text: text[m[0]:m[1]]})
+		}
+		b.Reset()
+		pos = pos[:0]
+	}
+	return hits
+}
@@ -0,0 +1,730 @@
+package vt
+
+// line is a line of a screen buffer.
+type line struct {
+	cells []Cell
+
+	// wrapped tells whether the text continues on the next line, i.e. the
+	// line was wrapped by autowrap rather than ended by a line feed.
+	wrapped bool
+}
+
+// buffer is the main or the alternate screen buffer.
+type buffer struct {
+	lines []line
+	saved cursor // Saved by DECSC.
+}
+
+// cursor is the state saved and restored by DECSC and DECRC.
+type cursor struct {
+	row, col int
+	style    Style
+	wrapNext bool // The last column has been written, wrap before the next character.
+	origin   bool // Origin mode (DECOM): positions are relative to the scroll region.
+	graphics bool // G0 is the DEC special graphics character set.
+}
+
+// terminal is the state of the emulated terminal. It implements Handler.
+type terminal struct {
+	rows, cols int
+
+	main, alt buffer
+	buf       *buffer // Current buffer.
+	cur       cursor
+
+	sb           *scrollback // Lines scrolled off the main buffer, nil if disabled.
+	top, bottom  int         // Scroll region.
+	autowrap     bool
+	insert       bool
+	cursorHidden bool
+	tabs         []bool
+	last         rune // Last printed character, for REP.
+	title        string
+}
+
+func newTerminal(rows, cols int) *terminal {
+	t := &terminal{rows: rows, cols: cols}
+	t.reset()
+	return t
+}
+
+// reset puts the terminal in its initial state (RIS).
+func (t *terminal) reset() {
+	t.cur = cursor{}
+	t.main = buffer{lines: t.blankLines(t.rows)}
+	t.alt = buffer{lines: t.blankLines(t.rows)}
+	t.buf = &t.main
+	t.top, t.bottom = 0, t.rows-1
+	t.autowrap = true
+	t.insert = false
+	t.cursorHidden = false
+	t.resetTabs(0)
+	t.last = 0
+	t.title = ""
+}
+
+func (t *terminal) blankLine() line {
+	return line{cells: blankCells(t.cols, t.cur.style)}
+}
+
+func blankCells(n int, style Style) []Cell {
+	cells := make([]Cell, n)
+	b := blank(style)
+	for i := range cells {
+		cells[i] = b
+	}
+	return cells
+}
+
+func (t *terminal) blankLines(n int) []line {
+	lines := make([]line, n)
+	for i := range lines {
+		lines[i] = t.blankLine()
+	}
+	return lines
+}
+
+// line returns the line row of the current buffer, or of the scrollback if
+// row is negative, nil if out of range.
+func (t *terminal) line(row int) *line {
+	switch {
+	case row >= 0 && row < t.rows:
+		return &t.buf.lines[row]
+	case row < 0 && t.sb != nil && -row <= len(t.sb.lines):
+		return &t.sb.lines[len(t.sb.lines)+row]
+	}
+	return nil
+}
+
+// resetTabs sets a tab stop every 8 columns from column from.
+func (t *terminal) resetTabs(from int) {
+	tabs := make([]bool, t.cols)
+	copy(tabs, t.tabs)
+	for i := from; i < t.cols; i++ {
+		tabs[i] = i%8 == 0
+	}
+	t.tabs = tabs
+}
+
+// resize resizes the screen buffers to rows and cols. The main buffer is
+// reflowed, see reflow. The alternate buffer is truncated: when rows
+// decrease, the lines below the cursor are removed first, then the top lines.
+func (t *terminal) resize(rows, cols int) {
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+
+	t.reflow(rows, cols)
+
+	cur := &t.alt.saved
+	if t.buf == &t.alt {
+		cur = &t.cur
+	}
+	for i := range t.alt.lines {
+		t.alt.lines[i].cells = resizeCells(t.alt.lines[i].cells, cols)
+	}
+	if excess := len(t.alt.lines) - rows; excess > 0 {
+		below := clamp(len(t.alt.lines)-1-cur.row, 0, excess)
+		t.alt.lines = t.alt.lines[excess-below : len(t.alt.lines)-below]
+		cur.row -= excess - below
+	}
+	for len(t.alt.lines) < rows {
+		t.alt.lines = append(t.alt.lines, line{cells: blankCells(cols, Style{})})
+	}
+
+	oldCols := t.cols
+	t.rows, t.cols = rows, cols
+	t.top, t.bottom = 0, rows-1
+	for _, c := range []*cursor{&t.cur, &t.main.saved, &t.alt.saved} {
+		c.row = clamp(c.row, 0, rows-1)
+		c.col = clamp(c.col, 0, cols-1)
+		c.wrapNext = false
+	}
+	t.resetTabs(oldCols)
+}
+
+// resizeCells truncates or extends cells to n cells.
+func resizeCells(cells []Cell, n int) []Cell {
+	if n > len(cells) {
+		return append(cells, blankCells(n-len(cells), Style{})...)
+	}
+	cells = cells[:n:n]
+	if last := &cells[n-1]; last.Wide {
+		// The second half of the character has been removed.
+		*last = blank(last.Style)
+	}
+	return cells
+}
+
+// Print implements Handler.
+func (t *terminal) Print(r rune) {
+	if t.cur.graphics && r >= 0x5f && r <= 0x7e {
+		r = decGraphics[r-0x5f]
+	}
+	w := runeWidth(r)
+	if w == 0 || w > t.cols {
+		return
+	}
+	t.last = r
+
+	if t.cur.wrapNext {
+		t.wrap()
+	}
+	if w == 2 && t.cur.col == t.cols-1 {
+		if !t.autowrap {
+			return
+		}
+		t.wrap()
+	}
+
+	cells := t.buf.lines[t.cur.row].cells
+	if t.insert {
+		t.clearWide(t.cur.row, t.cur.col)
+		copy(cells[t.cur.col+w:], cells[t.cur.col:])
+	}
+	t.clearWide(t.cur.row, t.cur.col)
+	if w == 2 {
+		t.clearWide(t.cur.row, t.cur.col+1)
+	}
+	cells[t.cur.col] = Cell{Rune: r, Wide: w == 2, Style: t.cur.style}
+	if w == 2 {
+		cells[t.cur.col+1] = Cell{Style: t.cur.style}
+	}
+	// The insertion may have split a wide character at the end of the line.
+	if last := &cells[t.cols-1]; last.Wide {
+		*last = blank(last.Style)
+	}
+
+	if t.cur.col += w; t.cur.col >= t.cols {
+		t.cur.col = t.cols - 1
+		t.cur.wrapNext = t.autowrap
+	}
+}
+
+// wrap moves the cursor to the start of the next line, as autowrap does.
+func (t *terminal) wrap() {
+	t.buf.lines[t.cur.row].wrapped = true
+	t.cur.col = 0
+	t.cur.wrapNext = false
+	t.index()
+}
+
+// clearWide erases the wide character of which the cell at row, col is a
+// half, before overwriting it.
+func (t *terminal) clearWide(row, col int) {
+	cells := t.buf.lines[row].cells
+	switch {
+	case cells[col].Wide && col+1 < t.cols:
+		cells[col+1] = blank(cells[col+1].Style)
+	case cells[col].Rune == 0 && col > 0:
+		cells[col-1] = blank(cells[col-1].Style)
+	default:
+		return
+	}
+	cells[col] = blank(cells[col].Style)
+}
+
+// Execute implements Handler.
+func (t *terminal) Execute(b byte) {
+	switch b {
+	case '\b':
+		t.cur.wrapNext = false
+		if t.cur.col > 0 {
+			t.cur.col--
+		}
+	case '\t':
+		t.tab(1)
+	case '\n', '\v', '\f':
+		t.index()
+	case '\r':
+		t.cur.col = 0
+		t.cur.wrapNext = false
+	case 0x0e, 0x0f: // SO, SI: G1 is not supported.
+	}
+}
+
+// tab moves the cursor to the n-th next tab stop, or previous if n < 0.
+func (t *terminal) tab(n int) {
+	t.cur.wrapNext = false
+	for ; n > 0 && t.cur.col < t.cols-1; n-- {
+		for t.cur.col++; t.cur.col < t.cols-1 && !t.tabs[t.cur.col]; t.cur.col++ {
+		}
+	}
+	for ; n < 0 && t.cur.col > 0; n++ {
+		for t.cur.col--; t.cur.col > 0 && !t.tabs[t.cur.col]; t.cur.col-- {
+		}
+	}
+}
+
+// index moves the cursor down, scrolling at the bottom of the scroll region.
+func (t *terminal) index() {
+	switch {
+	case t.cur.row == t.bottom:
+		t.scrollUp(t.top, 1)
+	case t.cur.row < t.rows-1:
+		t.cur.row++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling at the top of the scroll region.
+func (t *terminal) reverseIndex() {
+	switch {
+	case t.cur.row == t.top:
+		t.scrollDown(t.top, 1)
+	case t.cur.row > 0:
+		t.cur.row--
+	}
+}
+
+// scrollUp scrolls the lines between from and the bottom of the scroll
+// region up by n lines, inserting blank lines at the bottom.
+func (t *terminal) scrollUp(from, n int) {
+	lines := t.buf.lines[from : t.bottom+1]
+	if n > len(lines) {
+		n = len(lines)
+	}
+	if from == 0 && t.buf == &t.main {
+		t.sb.push(lines[:n]...)
+	}
+	copy(lines, lines[n:])
+	for i := len(lines) - n; i < len(lines); i++ {
+		lines[i] = t.blankLine()
+	}
+}
+
+// scrollDown scrolls the lines between from and the bottom of the scroll
+// region down by n lines, inserting blank lines at from.
+func (t *terminal) scrollDown(from, n int) {
+	lines := t.buf.lines[from : t.bottom+1]
+	if n > len(lines) {
+		n = len(lines)
+	}
+	copy(lines[n:], lines)
+	for i := 0; i < n; i++ {
+		lines[i] = t.blankLine()
+	}
+}
+
+// erase erases the cells of row between from and to excluded.
+func (t *terminal) erase(row, from, to int) {
+	if from >= to {
+		return
+	}
+	t.clearWide(row, from)
+	t.clearWide(row, to-1)
+	cells := t.buf.lines[row].cells
+	b := blank(t.cur.style)
+	for i := from; i < to; i++ {
+		cells[i] = b
+	}
+}
+
+// moveTo moves the cursor to row, col, relative to the scroll region in
+// origin mode.
+func (t *terminal) moveTo(row, col int) {
+	top, bottom := 0, t.rows-1
+	if t.cur.origin {
+		top, bottom = t.top, t.bottom
+	}
+	t.cur.row = clamp(row+top, top, bottom)
+	t.cur.col = clamp(col, 0, t.cols-1)
+	t.cur.wrapNext = false
+}
+
+// moveRow moves the cursor up or down by n lines, stopping at the margins
+// of the scroll region when the cursor is within.
+func (t *terminal) moveRow(n int) {
+	top, bottom := 0, t.rows-1
+	if t.cur.row >= t.top && t.cur.row <= t.bottom {
+		top, bottom = t.top, t.bottom
+	}
+	t.cur.row = clamp(t.cur.row+n, top, bottom)
+	t.cur.wrapNext = false
+}
+
+// ESC implements Handler.
+func (t *terminal) ESC(s Sequence) {
+	switch string(s.Intermediates) {
+	case "":
+		switch s.Final {
+		case '7': // DECSC
+			t.buf.saved = t.cur
+		case '8': // DECRC
+			t.restoreCursor()
+		case 'D': // IND
+			t.index()
+		case 'E': // NEL
+			t.cur.col = 0
+			t.index()
+		case 'H': // HTS
+			t.tabs[t.cur.col] = true
+		case 'M': // RI
+			t.reverseIndex()
+		case 'c': // RIS
+			t.reset()
+		}
+	case "(": // Designate G0.
+		t.cur.graphics = s.Final == '0'
+	case "#":
+		if s.Final == '8' { // DECALN
+			for _, l := range t.buf.lines {
+				for i := range l.cells {
+					l.cells[i] = Cell{Rune: 'E'}
+				}
+			}
+		}
+	}
+}
+
+func (t *terminal) restoreCursor() {
+	t.cur = t.buf.saved
+	t.cur.row = clamp(t.cur.row, 0, t.rows-1)
+	t.cur.col = clamp(t.cur.col, 0, t.cols-1)
+}
+
+// CSI implements Handler.
+func (t *terminal) CSI(s Sequence) {
+	if len(s.Intermediates) > 0 {
+		return
+	}
+	if s.Private != 0 {
+		if s.Private == '?' && (s.Final == 'h' || s.Final == 'l') {
+			for i := range s.Params {
+				t.setPrivateMode(s.Params.Get(i, 0), s.Final == 'h')
+			}
+		}
+		return
+	}
+
+	// Most functions take a count, where 0 means 1.
+	n := s.Params.Get(0, 1)
+	if n == 0 {
+		n = 1
+	}
+	switch s.Final {
+	case '@': // ICH
+		t.clearWide(t.cur.row, t.cur.col)
+		cells := t.buf.lines[t.cur.row].cells[t.cur.col:]
+		n = clamp(n, 0, len(cells))
+		copy(cells[n:], cells)
+		t.erase(t.cur.row, t.cur.col, t.cur.col+n)
+	case 'A': // CUU
+		t.moveRow(-n)
+	case 'B', 'e': // CUD, VPR
+		t.moveRow(n)
+	case 'C', 'a': // CUF, HPR
+		t.cur.col = clamp(t.cur.col+n, 0, t.cols-1)
+		t.cur.wrapNext = false
+	case 'D': // CUB
+		t.cur.col = clamp(t.cur.col-n, 0, t.cols-1)
+		t.cur.wrapNext = false
+	case 'E': // CNL
+		t.moveRow(n)
+		t.cur.col = 0
+	case 'F': // CPL
+		t.moveRow(-n)
+		t.cur.col = 0
+	case 'G', '`': // CHA, HPA
+		t.cur.col = clamp(n-1, 0, t.cols-1)
+		t.cur.wrapNext = false
+	case 'H', 'f': // CUP, HVP
+		col := s.Params.Get(1, 1)
+		if col == 0 {
+			col = 1
+		}
+		t.moveTo(n-1, col-1)
+	case 'I': // CHT
+		t.tab(n)
+	case 'J': // ED
+		t.eraseDisplay(s.Params.Get(0, 0))
+	case 'K': // EL
+		t.eraseLine(s.Params.Get(0, 0))
+	case 'L': // IL
+		if t.cur.row >= t.top && t.cur.row <= t.bottom {
+			t.scrollDown(t.cur.row, n)
+			t.cur.col = 0
+			t.cur.wrapNext = false
+		}
+	case 'M': // DL
+		if t.cur.row >= t.top && t.cur.row <= t.bottom {
+			t.scrollUp(t.cur.row, n)
+			t.cur.col = 0
+			t.cur.wrapNext = false
+		}
+	case 'P': // DCH
+		t.clearWide(t.cur.row, t.cur.col)
+		cells := t.buf.lines[t.cur.row].cells[t.cur.col:]
+		n = clamp(n, 0, len(cells))
+		copy(cells, cells[n:])
+		t.erase(t.cur.row, t.cols-n, t.cols)
+	case 'S': // SU
+		t.scrollUp(t.top, n)
+	case 'T': // SD
+		if len(s.Params) <= 1 {
+			t.scrollDown(t.top, n)
+		}
+	case 'X': // ECH
+		t.erase(t.cur.row, t.cur.col, clamp(t.cur.col+n, 0, t.cols))
+	case 'Z': // CBT
+		t.tab(-n)
+	case 'b': // REP
+		if t.last != 0 {
+			for i := 0; i < n && i < t.rows*t.cols; i++ {
+				t.Print(t.last)
+			}
+		}
+	case 'd': // VPA
+		t.moveTo(n-1, t.cur.col)
+	case 'g': // TBC
+		switch s.Params.Get(0, 0) {
+		case 0:
+			t.tabs[t.cur.col] = false
+		case 3:
+			for i := range t.tabs {
+				t.tabs[i] = false
+			}
+		}
+	case 'h', 'l': // SM, RM
+		for i := range s.Params {
+			if s.Params.Get(i, 0) == 4 { // IRM
+				t.insert = s.Final == 'h'
+			}
+		}
+	case 'm': // SGR
+		t.sgr(s.Params)
+	case 'r': // DECSTBM
+		top, bottom := s.Params.Get(0, 1), s.Params.Get(1, t.rows)
+		if top == 0 {
+			top = 1
+		}
+		if bottom == 0 || bottom > t.rows {
+			bottom = t.rows
+		}
+		if top < bottom {
+			t.top, t.bottom = top-1, bottom-1
+			t.moveTo(0, 0)
+		}
+	case 's': // SCOSC
+		if len(s.Params) == 0 {
+			t.buf.saved = t.cur
+		}
+	case 'u': // SCORC
+		if len(s.Params) == 0 {
+			t.restoreCursor()
+		}
+	}
+}
+
+func (t *terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.eraseLine(0)
+		for row := t.cur.row + 1; row < t.rows; row++ {
+			t.erase(row, 0, t.cols)
+		}
+	case 1:
+		t.eraseLine(1)
+		for row := 0; row < t.cur.row; row++ {
+			t.erase(row, 0, t.cols)
+		}
+	case 2:
+		for row := 0; row < t.rows; row++ {
+			t.erase(row, 0, t.cols)
+		}
+	case 3:
+		t.sb.clear()
+	}
+}
+
+func (t *terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.erase(t.cur.row, t.cur.col, t.cols)
+		t.buf.lines[t.cur.row].wrapped = false
+	case 1:
+		t.erase(t.cur.row, 0, t.cur.col+1)
+	case 2:
+		t.erase(t.cur.row, 0, t.cols)
+		t.buf.lines[t.cur.row].wrapped = false
+	}
+}
+
+func (t *terminal) setPrivateMode(mode int, set bool) {
+	switch mode {
+	case 6: // DECOM
+		t.cur.origin = set
+		t.moveTo(0, 0)
+	case 7: // DECAWM
+		t.autowrap = set
+		t.cur.wrapNext = false
+	case 25: // DECTCEM
+		t.cursorHidden = !set
+	case 47, 1047:
+		t.switchBuffer(set, false)
+	case 1049:
+		t.switchBuffer(set, true)
+	}
+}
+
+// switchBuffer switches to the alternate screen buffer when alt is set,
+// which is cleared, or back to the main one. The cursor is saved before
+// switching to the alternate buffer and restored after when saveCursor is set.
+func (t *terminal) switchBuffer(alt, saveCursor bool) {
+	if alt == (t.buf == &t.alt) {
+		return
+	}
+	if !alt {
+		t.buf = &t.main
+		if saveCursor {
+			t.restoreCursor()
+		}
+		return
+	}
+	if saveCursor {
+		t.main.saved = t.cur
+	}
+	t.buf = &t.alt
+	for row := range t.alt.lines {
+		t.alt.lines[row] = t.blankLine()
+	}
+}
+
+// sgr sets the graphic rendition of the cursor.
+func (t *terminal) sgr(params Params) {
+	style := &t.cur.style
+	if len(params) == 0 {
+		*style = Style{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		switch p := params.Get(i, 0); {
+		case p == 0:
+			*style = Style{}
+		case p == 1:
+			style.Attr |= AttrBold
+		case p == 2:
+			style.Attr |= AttrFaint
+		case p == 3:
+			style.Attr |= AttrItalic
+		case p == 4:
+			if len(params[i]) > 1 && params[i][1] == 0 {
+				style.Attr &^= AttrUnderline
+			} else {
+				style.Attr |= AttrUnderline
+			}
+		case p == 5 || p == 6:
+			style.Attr |= AttrBlink
+		case p == 7:
+			style.Attr |= AttrReverse
+		case p == 8:
+			style.Attr |= AttrHidden
+		case p == 9:
+			style.Attr |= AttrStrike
+		case p == 21:
+			style.Attr |= AttrUnderline
+		case p == 22:
+			style.Attr &^= AttrBold | AttrFaint
+		case p == 23:
+			style.Attr &^= AttrItalic
+		case p == 24:
+			style.Attr &^= AttrUnderline
+		case p == 25:
+			style.Attr &^= AttrBlink
+		case p == 27:
+			style.Attr &^= AttrReverse
+		case p == 28:
+			style.Attr &^= AttrHidden
+		case p == 29:
+			style.Attr &^= AttrStrike
+		case p >= 30 && p <= 37:
+			style.Fg = IndexedColor(uint8(p - 30))
+		case p == 38:
+			style.Fg, i = extendedColor(params, i, style.Fg)
+		case p == 39:
+			style.Fg = DefaultColor
+		case p >= 40 && p <= 47:
+			style.Bg = IndexedColor(uint8(p - 40))
+		case p == 48:
+			style.Bg, i = extendedColor(params, i, style.Bg)
+		case p == 49:
+			style.Bg = DefaultColor
+		case p >= 90 && p <= 97:
+			style.Fg = IndexedColor(uint8(p - 90 + 8))
+		case p >= 100 && p <= 107:
+			style.Bg = IndexedColor(uint8(p - 100 + 8))
+		}
+	}
+}
+
+// extendedColor parses the color of SGR 38 or 48 at params[i], either
+// with sub-parameters ("38:5:n", "38:2::r:g:b") or parameters ("38;5;n",
+// "38;2;r;g;b"). It returns the color, c if invalid, and the index of the
+// last parameter used.
+func extendedColor(params Params, i int, c Color) (Color, int) {
+	values, colon := params[i][1:], len(params[i]) > 1
+	if !colon {
+		for _, p := range params[i+1:] {
+			values = append(values, p[0])
+		}
+	}
+	if len(values) < 2 {
+		return c, len(params)
+	}
+
+	component := func(v int) uint8 { return uint8(clamp(v, 0, 255)) }
+	switch values[0] {
+	case 5:
+		if !colon {
+			i += 2
+		}
+		return IndexedColor(component(values[1])), i
+	case 2:
+		rgb := values[1:]
+		if colon && len(rgb) > 3 {
+			// The color space identifier precedes the components.
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return c, len(params)
+		}
+		if !colon {
+			i += 4
+		}
+		return RGBColor(component(rgb[0]), component(rgb[1]), component(rgb[2])), i
+	}
+	return c, len(params)
+}
+
+// OSC implements Handler.
+func (t *terminal) OSC(data []byte) {
+	// Set the window title.
+	if len(data) > 1 && (data[0] == '0' || data[0] == '2') && data[1] == ';' {
+		t.title = string(data[2:])
+	}
+}
+
+// DCS implements Handler.
+func (t *terminal) DCS(Sequence, []byte) {}
+
+// APC implements Handler.
+func (t *terminal) APC([]byte) {}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// decGraphics maps the characters 0x5f to 0x7e of the DEC special graphics
+// character set, used to draw lines.
+var decGraphics = [...]rune{
+	' ', '◆', '▒', '␉', '␌', '␍', '␊', '°', '±', '␤', '␋', '┘', '┐', '┌', '└', '┼',
+	'⎺', '⎻', '─', '⎼', '⎽', '├', '┤', '┴', '┬', '│', '≤', '≥', 'π', '≠', '£', '·',
+}
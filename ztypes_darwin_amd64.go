@@ -0,0 +1,96 @@
+//go:build amd64 && darwin
+// +build amd64,darwin
+
+// Created by cgo -godefs - DO NOT EDIT
+// cgo -godefs types_darwin.go
+
+package pty
+
+type Termios struct {
+	Iflag  uint64
+	Oflag  uint64
+	Cflag  uint64
+	Lflag  uint64
+	Cc     [20]uint8
+	Ispeed uint64
+	Ospeed uint64
+}
+
+const (
+	ioctl_TIOCGETA  = 0x40487413
+	ioctl_TIOCSETA  = 0x80487414
+	ioctl_TIOCSETAW = 0x80487415
+	ioctl_TIOCSETAF = 0x80487416
+)
+
+const (
+	IGNBRK  = 0x1
+	BRKINT  = 0x2
+	IGNPAR  = 0x4
+	PARMRK  = 0x8
+	INPCK   = 0x10
+	ISTRIP  = 0x20
+	INLCR   = 0x40
+	IGNCR   = 0x80
+	ICRNL   = 0x100
+	IXON    = 0x200
+	IXANY   = 0x800
+	IXOFF   = 0x400
+	IMAXBEL = 0x2000
+)
+
+const (
+	OPOST  = 0x1
+	ONLCR  = 0x2
+	OCRNL  = 0x10
+	ONOCR  = 0x20
+	ONLRET = 0x40
+)
+
+const (
+	CSIZE  = 0x300
+	CS5    = 0x0
+	CS6    = 0x100
+	CS7    = 0x200
+	CS8    = 0x300
+	CSTOPB = 0x400
+	CREAD  = 0x800
+	PARENB = 0x1000
+	PARODD = 0x2000
+	HUPCL  = 0x4000
+	CLOCAL = 0x8000
+)
+
+const (
+	ISIG    = 0x80
+	ICANON  = 0x100
+	ECHO    = 0x8
+	ECHOE   = 0x2
+	ECHOK   = 0x4
+	ECHONL  = 0x10
+	ECHOCTL = 0x40
+	ECHOKE  = 0x1
+	NOFLSH  = 0x80000000
+	TOSTOP  = 0x400000
+	IEXTEN  = 0x400
+	EXTPROC = 0x800
+)
+
+const (
+	VINTR    = 0x8
+	VQUIT    = 0x9
+	VERASE   = 0x3
+	VKILL    = 0x5
+	VEOF     = 0x0
+	VTIME    = 0x11
+	VMIN     = 0x10
+	VSTART   = 0xc
+	VSTOP    = 0xd
+	VSUSP    = 0xa
+	VEOL     = 0x1
+	VEOL2    = 0x2
+	VWERASE  = 0x4
+	VREPRINT = 0x6
+	VLNEXT   = 0xe
+	VDISCARD = 0xf
+)
@@ -0,0 +1,101 @@
+//go:build (ppc || ppc64 || ppc64le) && linux
+// +build ppc ppc64 ppc64le
+// +build linux
+
+// Created by cgo -godefs - DO NOT EDIT
+// cgo -godefs types_linux.go
+
+package pty
+
+type Termios struct {
+	Iflag  uint32
+	Oflag  uint32
+	Cflag  uint32
+	Lflag  uint32
+	Cc     [19]uint8
+	Line   uint8
+	Ispeed uint32
+	Ospeed uint32
+}
+
+const (
+	ioctl_TCGETS      = 0x402c7413
+	ioctl_TCSETS      = 0x802c7414
+	ioctl_TCSETSW     = 0x802c7415
+	ioctl_TCSETSF     = 0x802c7416
+	ioctl_TIOCGPTPEER = 0x20005441
+)
+
+const _AT_FDCWD = -0x64
+
+const (
+	IGNBRK  = 0x1
+	BRKINT  = 0x2
+	IGNPAR  = 0x4
+	PARMRK  = 0x8
+	INPCK   = 0x10
+	ISTRIP  = 0x20
+	INLCR   = 0x40
+	IGNCR   = 0x80
+	ICRNL   = 0x100
+	IXON    = 0x200
+	IXANY   = 0x800
+	IXOFF   = 0x400
+	IMAXBEL = 0x2000
+)
+
+const (
+	OPOST  = 0x1
+	ONLCR  = 0x2
+	OCRNL  = 0x8
+	ONOCR  = 0x10
+	ONLRET = 0x20
+)
+
+const (
+	CSIZE  = 0x300
+	CS5    = 0x0
+	CS6    = 0x100
+	CS7    = 0x200
+	CS8    = 0x300
+	CSTOPB = 0x400
+	CREAD  = 0x800
+	PARENB = 0x1000
+	PARODD = 0x2000
+	HUPCL  = 0x4000
+	CLOCAL = 0x8000
+)
+
+const (
+	ISIG    = 0x80
+	ICANON  = 0x100
+	ECHO    = 0x8
+	ECHOE   = 0x2
+	ECHOK   = 0x4
+	ECHONL  = 0x10
+	ECHOCTL = 0x40
+	ECHOKE  = 0x1
+	NOFLSH  = 0x80000000
+	TOSTOP  = 0x400000
+	IEXTEN  = 0x400
+	EXTPROC = 0x10000000
+)
+
+const (
+	VINTR    = 0x0
+	VQUIT    = 0x1
+	VERASE   = 0x2
+	VKILL    = 0x3
+	VEOF     = 0x4
+	VTIME    = 0x7
+	VMIN     = 0x5
+	VSTART   = 0xd
+	VSTOP    = 0xe
+	VSUSP    = 0xc
+	VEOL     = 0x6
+	VEOL2    = 0x8
+	VWERASE  = 0xa
+	VREPRINT = 0xb
+	VLNEXT   = 0xf
+	VDISCARD = 0x10
+)
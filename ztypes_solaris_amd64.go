@@ -0,0 +1,94 @@
+//go:build amd64 && solaris
+// +build amd64,solaris
+
+// Created by cgo -godefs - DO NOT EDIT
+// cgo -godefs types_solaris.go
+
+package pty
+
+type Termios struct {
+	Iflag uint32
+	Oflag uint32
+	Cflag uint32
+	Lflag uint32
+	Cc    [19]uint8
+	_     [1]byte
+}
+
+const (
+	ioctl_TCGETS  = 0x540d
+	ioctl_TCSETS  = 0x540e
+	ioctl_TCSETSW = 0x540f
+	ioctl_TCSETSF = 0x5410
+)
+
+const (
+	IGNBRK  = 0x1
+	BRKINT  = 0x2
+	IGNPAR  = 0x4
+	PARMRK  = 0x8
+	INPCK   = 0x10
+	ISTRIP  = 0x20
+	INLCR   = 0x40
+	IGNCR   = 0x80
+	ICRNL   = 0x100
+	IXON    = 0x400
+	IXANY   = 0x800
+	IXOFF   = 0x1000
+	IMAXBEL = 0x2000
+)
+
+const (
+	OPOST  = 0x1
+	ONLCR  = 0x4
+	OCRNL  = 0x8
+	ONOCR  = 0x10
+	ONLRET = 0x20
+)
+
+const (
+	CSIZE  = 0x30
+	CS5    = 0x0
+	CS6    = 0x10
+	CS7    = 0x20
+	CS8    = 0x30
+	CSTOPB = 0x40
+	CREAD  = 0x80
+	PARENB = 0x100
+	PARODD = 0x200
+	HUPCL  = 0x400
+	CLOCAL = 0x800
+)
+
+const (
+	ISIG    = 0x1
+	ICANON  = 0x2
+	ECHO    = 0x8
+	ECHOE   = 0x10
+	ECHOK   = 0x20
+	ECHONL  = 0x40
+	ECHOCTL = 0x200
+	ECHOKE  = 0x800
+	NOFLSH  = 0x80
+	TOSTOP  = 0x100
+	IEXTEN  = 0x8000
+)
+
+const (
+	VINTR    = 0x0
+	VQUIT    = 0x1
+	VERASE   = 0x2
+	VKILL    = 0x3
+	VEOF     = 0x4
+	VTIME    = 0x5
+	VMIN     = 0x4
+	VSTART   = 0x8
+	VSTOP    = 0x9
+	VSUSP    = 0xa
+	VEOL     = 0x5
+	VEOL2    = 0x6
+	VWERASE  = 0xe
+	VREPRINT = 0xc
+	VLNEXT   = 0xf
+	VDISCARD = 0xd
+)